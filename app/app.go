@@ -2,6 +2,8 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -9,36 +11,168 @@ import (
 	"time"
 )
 
+const defaultShutdownTimeout = 10 * time.Second
+
 // Runner encapsulates the startup logic.
 // It handles signals and context cancellation so you don't have to write it 50 times.
 type Runner struct {
 	Logger *slog.Logger
+
+	// ShutdownTimeout is the total budget shared by every component's Stop.
+	ShutdownTimeout time.Duration
+
+	components []namedComponent
+	printMode  printMode
+}
+
+type namedComponent struct {
+	name string
+	Component
 }
 
 func NewRunner(logger *slog.Logger) *Runner {
-	return &Runner{Logger: logger}
+	return &Runner{
+		Logger:          logger,
+		ShutdownTimeout: defaultShutdownTimeout,
+	}
+}
+
+// Register adds a component to the lifecycle.
+// Components start in registration order and stop in reverse order, so register
+// dependencies first: Postgres, Redis, Kafka producer, audit logger, consumers, server.
+// It must be called from the setup function passed to Run.
+func (r *Runner) Register(name string, c Component) {
+	r.components = append(r.components, namedComponent{name: name, Component: c})
 }
 
 // Run executes the main logic function. It provides a context that cancels on SIGTERM/SIGINT.
+// After fn returns, registered components are started. On signal (or if a Service
+// exits on its own) they are stopped in reverse order within ShutdownTimeout.
 func (r *Runner) Run(fn func(ctx context.Context) error) {
 	// Create context that listens for the kill signal
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	r.Logger.Info("Service starting...")
+	mode, err := parsePrintMode(os.Args[1:])
+	if err != nil {
+		r.Logger.Error("Invalid command line", "error", err)
+		stop()
+		os.Exit(2)
+	}
+	r.printMode = mode
+
+	if mode.flag == "" {
+		r.Logger.Info("Service starting...")
+	}
 
 	if err := fn(ctx); err != nil {
+		if errors.Is(err, errPrinted) {
+			stop()
+			os.Exit(0)
+		}
 		r.Logger.Error("Service startup failed", "error", err)
 		stop()
 		os.Exit(1)
 	}
 
-	<-ctx.Done()
+	// Components outlive the signal context: each one is stopped explicitly, in order.
+	lifeCtx, cancelLife := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelLife()
 
-	// Graceful shutdown period
-	r.Logger.Info("Shutdown signal received. Cleaning up...")
-	_, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	started, err := r.start(ctx, lifeCtx)
+	exitCode := 0
+	if err != nil {
+		r.Logger.Error("Service startup failed", "error", err)
+		exitCode = 1
+	} else {
+		if err := r.wait(ctx, started); err != nil {
+			r.Logger.Error("Component exited unexpectedly", "error", err)
+			exitCode = 1
+		} else {
+			r.Logger.Info("Shutdown signal received. Cleaning up...")
+		}
+	}
+
+	if err := r.shutdown(started); err != nil {
+		exitCode = 1
+	}
+	cancelLife()
 
 	r.Logger.Info("Service shutdown complete.")
+	if exitCode != 0 {
+		stop()
+		os.Exit(exitCode)
+	}
+}
+
+// start brings components up in registration order and returns the ones that started.
+// It stops early if the signal arrives mid-startup.
+func (r *Runner) start(sigCtx, lifeCtx context.Context) ([]namedComponent, error) {
+	started := make([]namedComponent, 0, len(r.components))
+	for _, c := range r.components {
+		if sigCtx.Err() != nil {
+			break
+		}
+
+		r.Logger.Debug("Starting component", "component", c.name)
+		if err := c.Start(lifeCtx); err != nil {
+			return started, fmt.Errorf("start %s: %w", c.name, err)
+		}
+		started = append(started, c)
+	}
+	return started, nil
+}
+
+// wait blocks until the signal arrives or a component exits on its own.
+func (r *Runner) wait(sigCtx context.Context, started []namedComponent) error {
+	exited := make(chan error, len(started))
+	for _, c := range started {
+		ex, ok := c.Component.(exiter)
+		if !ok {
+			continue
+		}
+		go func(name string) {
+			select {
+			case <-ex.Done():
+				err := ex.Err()
+				if err == nil {
+					err = errors.New("exited without error")
+				}
+				exited <- fmt.Errorf("%s: %w", name, err)
+			case <-sigCtx.Done():
+			}
+		}(c.name)
+	}
+
+	select {
+	case <-sigCtx.Done():
+		return nil
+	case err := <-exited:
+		return err
+	}
+}
+
+// shutdown stops components in reverse order under a single shared budget.
+// Every component gets its Stop call even if the budget is already spent,
+// so pools and files are still released.
+func (r *Runner) shutdown(started []namedComponent) error {
+	timeout := r.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		begin := time.Now()
+		if err := c.Stop(ctx); err != nil {
+			r.Logger.Error("Component shutdown failed", "component", c.name, "error", err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+			continue
+		}
+		r.Logger.Info("Component stopped", "component", c.name, "duration_ms", time.Since(begin).Milliseconds())
+	}
+	return errors.Join(errs...)
 }
@@ -2,36 +2,40 @@ package app
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/kelseyhightower/envconfig"
+	"github.com/godamri/helix-fnd/config"
 )
 
 // Loader standardizes how we load configuration.
-// It combines envconfig (for parsing) and validator/v10 (for enforcement).
+// It is the non-generic front of config.Process: `default` tags, YAML layers,
+// .env files and env vars (envconfig naming), then validator/v10 enforcement.
 type Loader struct {
-	validate *validator.Validate
+	opts []config.Option
 }
 
-func NewConfigLoader() *Loader {
+// NewConfigLoader accepts the same sources as config.NewLoader,
+// e.g. config.WithFile("config.yaml"), config.WithEnvironment("prod"), config.WithDotEnv(".env").
+func NewConfigLoader(opts ...config.Option) *Loader {
+	// Share one validator and secret cache across loads.
+	defaults := []config.Option{
+		config.WithValidator(validator.New()),
+		config.WithSecrets(config.NewSecrets(0)),
+	}
 	return &Loader{
-		validate: validator.New(),
+		opts: append(defaults, opts...),
 	}
 }
 
-// Load reads env vars into the provided spec struct and validates it.
+// Load reads all sources into the provided spec struct and validates it.
 // If this fails, the application SHOULD panic and die.
 func (l *Loader) Load(ctx context.Context, spec interface{}, prefix string) error {
-	// Parse Env Vars
-	if err := envconfig.Process(prefix, spec); err != nil {
-		return fmt.Errorf("config: failed to process env vars: %w", err)
-	}
-
-	// Validate Constraints (min, max, required, etc.)
-	if err := l.validate.Struct(spec); err != nil {
-		return fmt.Errorf("config: validation failed: %w", err)
-	}
+	_, err := l.LoadWithReport(ctx, spec, prefix)
+	return err
+}
 
-	return nil
+// LoadWithReport is Load plus a description of every value and its source.
+// Secrets are redacted in the report, so log the report, never the spec.
+func (l *Loader) LoadWithReport(ctx context.Context, spec interface{}, prefix string) (*config.Report, error) {
+	return config.Process(ctx, prefix, spec, l.opts...)
 }
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/godamri/helix-fnd/config"
+)
+
+const (
+	flagPrintConfig       = "print-config"
+	flagPrintEnvReference = "print-env-reference"
+
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+)
+
+// errPrinted stops the setup function after an introspection flag was served.
+var errPrinted = errors.New("app: configuration printed")
+
+// printMode is parsed from the command line:
+//
+//	--print-config[=json|markdown]          effective values (secrets redacted), default json
+//	--print-env-reference[=markdown|json]   every env var with type/default/required, default markdown
+type printMode struct {
+	flag   string
+	format string
+}
+
+func parsePrintMode(args []string) (printMode, error) {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, format, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+
+		switch name {
+		case flagPrintConfig:
+			if format == "" {
+				format = formatJSON
+			}
+		case flagPrintEnvReference:
+			if format == "" {
+				format = formatMarkdown
+			}
+		default:
+			continue
+		}
+
+		if format != formatJSON && format != formatMarkdown {
+			return printMode{}, fmt.Errorf("unsupported --%s format %q (use json or markdown)", name, format)
+		}
+		return printMode{flag: name, format: format}, nil
+	}
+	return printMode{}, nil
+}
+
+// LoadConfig loads spec through app.Loader and serves the introspection flags.
+//
+// With --print-env-reference it prints the env var reference without loading anything.
+// With --print-config it loads, prints the effective values and their sources, then stops.
+// In both cases it returns a sentinel error that Run turns into a clean exit,
+// so the setup function must return it (as it does for any load error).
+func (r *Runner) LoadConfig(ctx context.Context, spec interface{}, prefix string, opts ...config.Option) error {
+	switch r.printMode.flag {
+	case flagPrintEnvReference:
+		report, err := config.Describe(prefix, spec)
+		if err != nil {
+			return err
+		}
+		if err := writeReport(os.Stdout, report, r.printMode.format); err != nil {
+			return err
+		}
+		return errPrinted
+
+	case flagPrintConfig:
+		report, err := NewConfigLoader(opts...).LoadWithReport(ctx, spec, prefix)
+		if report != nil {
+			if werr := writeReport(os.Stdout, report, r.printMode.format); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return errPrinted
+	}
+
+	report, err := NewConfigLoader(opts...).LoadWithReport(ctx, spec, prefix)
+	if err != nil {
+		return err
+	}
+	r.Logger.Debug("Configuration loaded", "config", report)
+	return nil
+}
+
+func writeReport(w io.Writer, report *config.Report, format string) error {
+	if format == formatMarkdown {
+		return report.WriteMarkdown(w)
+	}
+	return report.WriteJSON(w)
+}
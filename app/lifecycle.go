@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"io"
+)
+
+// Component is anything with a managed lifecycle.
+// Start must return once the component is ready (run long-lived work in a goroutine).
+// Stop must release resources and honor the deadline carried by ctx.
+type Component interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Hook adapts plain functions into a Component. Nil functions are no-ops.
+//
+//	runner.Register("postgres", app.Hook{OnStop: func(context.Context) error { pool.Close(); return nil }})
+type Hook struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+func (h Hook) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx)
+}
+
+func (h Hook) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+// Closer adapts an io.Closer (redis client, audit logger, Kafka producer) into a Component.
+// Close runs in the background so a slow flush cannot exceed the shutdown budget.
+func Closer(c io.Closer) Component {
+	return Hook{
+		OnStop: func(ctx context.Context) error {
+			done := make(chan error, 1)
+			go func() { done <- c.Close() }()
+
+			select {
+			case err := <-done:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Service adapts a blocking run function into a Component.
+// The function runs in a goroutine until Stop cancels its context, which matches
+// server.Server.Start: it serves until ctx is done and then drains connections.
+//
+// If the function returns before Stop is called, the Runner treats it as a fatal
+// exit and shuts the whole service down.
+func Service(run func(ctx context.Context) error) Component {
+	return &service{run: run}
+}
+
+type service struct {
+	run    func(ctx context.Context) error
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func (s *service) Start(ctx context.Context) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		s.err = s.run(ctx)
+	}()
+
+	return nil
+}
+
+func (s *service) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	select {
+	case <-s.done:
+		return s.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Done is closed when the run function returns.
+func (s *service) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err reports why the run function returned. Only valid after Done is closed.
+func (s *service) Err() error {
+	return s.err
+}
+
+// exiter is implemented by components that can stop on their own (see Service).
+type exiter interface {
+	Done() <-chan struct{}
+	Err() error
+}
@@ -21,13 +21,18 @@ type AsyncLogger struct {
 	logger      *slog.Logger
 	closeOnce   sync.Once
 	blockOnFull bool
+	procs       []Processor
+	chain       *Chain
+	spool       *Spool
+	metrics     *Metrics
+	sink        string
 
 	// Drop Strategy Stats
 	dropCount   uint64
 	lastLogTime atomic.Value
 }
 
-func NewAsyncLogger(w io.Writer, bufferSize int, blockOnFull bool, logger *slog.Logger) *AsyncLogger {
+func NewAsyncLogger(w io.Writer, bufferSize int, blockOnFull bool, logger *slog.Logger, opts ...Option) *AsyncLogger {
 	if w == nil {
 		w = os.Stdout
 	}
@@ -41,6 +46,14 @@ func NewAsyncLogger(w io.Writer, bufferSize int, blockOnFull bool, logger *slog.
 		logger:      logger,
 		blockOnFull: blockOnFull,
 	}
+	o := applyOptions(opts)
+	l.procs = o.procs
+	l.chain = o.chain
+	l.spool = o.spool
+	l.metrics, l.sink = o.metrics, o.sink
+	if l.sink == "" {
+		l.sink = "async"
+	}
 	l.lastLogTime.Store(time.Unix(0, 0))
 
 	l.wg.Add(1)
@@ -53,6 +66,33 @@ func (l *AsyncLogger) Log(ctx context.Context, event Event) error {
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now()
 	}
+	if skip, err := process(ctx, l.procs, &event); skip || err != nil {
+		return err
+	}
+
+	start := time.Now()
+	defer func() {
+		l.metrics.observeEnqueue(l.sink, start)
+		l.metrics.setDepth(l.sink, len(l.events))
+	}()
+
+	if l.spool != nil {
+		// STRATEGY: Spill Over. Never blocks, never drops (until the spool is full).
+		// Once anything is spilled, later events follow it to disk so replay keeps order.
+		if !l.spool.Pending() {
+			select {
+			case l.events <- event:
+				return nil
+			default:
+			}
+		}
+		if err := l.spool.Append(event); err != nil {
+			l.handleDrop(event.Action, DropSpoolFull)
+			return err
+		}
+		l.metrics.spill(l.sink)
+		return nil
+	}
 
 	if l.blockOnFull {
 		// STRATEGY: High Consistency
@@ -60,7 +100,7 @@ func (l *AsyncLogger) Log(ctx context.Context, event Event) error {
 		case l.events <- event:
 			return nil
 		case <-ctx.Done():
-			l.handleDrop(event.Action)
+			l.handleDrop(event.Action, DropCanceled)
 			return ctx.Err()
 		}
 	} else {
@@ -69,14 +109,15 @@ func (l *AsyncLogger) Log(ctx context.Context, event Event) error {
 		case l.events <- event:
 			return nil
 		default:
-			l.handleDrop(event.Action)
+			l.handleDrop(event.Action, DropBufferFull)
 			return ErrAuditBufferFull
 		}
 	}
 }
 
-func (l *AsyncLogger) handleDrop(action string) {
+func (l *AsyncLogger) handleDrop(action, reason string) {
 	atomic.AddUint64(&l.dropCount, 1)
+	l.metrics.addDropped(l.sink, reason, 1)
 
 	now := time.Now()
 	lastLog, ok := l.lastLogTime.Load().(time.Time)
@@ -91,7 +132,7 @@ func (l *AsyncLogger) handleDrop(action string) {
 
 		l.logger.Error("AUDIT_LOG_CRITICAL_FAILURE",
 			slog.Uint64("dropped_count", totalDropped),
-			slog.String("reason", "buffer_full_or_timeout"),
+			slog.String("reason", reason),
 			slog.String("sample_action", action),
 			slog.Bool("blocking_mode", l.blockOnFull),
 		)
@@ -102,17 +143,78 @@ func (l *AsyncLogger) worker() {
 	defer l.wg.Done()
 	encoder := json.NewEncoder(l.writer)
 
-	for event := range l.events {
-		if err := encoder.Encode(event); err != nil {
-			l.logger.Error("audit_write_failed", slog.String("err", err.Error()))
+	var spilled <-chan struct{}
+	if l.spool != nil {
+		spilled = l.spool.notify
+	}
+
+	for {
+		// Buffered events are always older than spilled ones: write them first.
+		select {
+		case event, ok := <-l.events:
+			if !ok {
+				return
+			}
+			l.write(encoder, event)
+			continue
+		default:
 		}
+
+		if l.spool != nil && l.replay(encoder) {
+			continue
+		}
+
+		select {
+		case event, ok := <-l.events:
+			if !ok {
+				return
+			}
+			l.write(encoder, event)
+		case <-spilled:
+		}
+	}
+}
+
+// replay writes one spilled event. It reports whether the spool had anything.
+func (l *AsyncLogger) replay(encoder *json.Encoder) bool {
+	event, ok, err := l.spool.Next()
+	if err != nil {
+		l.logger.Error("audit_spool_read_failed", slog.String("err", err.Error()))
+	}
+	if ok && err == nil {
+		l.write(encoder, event)
 	}
+	return ok
 }
 
+func (l *AsyncLogger) write(encoder *json.Encoder, event Event) {
+	// Sealed here, in the single writer, so sequence order is file order.
+	if l.chain != nil {
+		if err := l.chain.Seal(&event); err != nil {
+			l.logger.Error("audit_seal_failed", slog.String("err", err.Error()), slog.String("action", event.Action))
+			l.metrics.addDropped(l.sink, DropSealFailed, 1)
+			return
+		}
+	}
+	if err := encoder.Encode(event); err != nil {
+		l.logger.Error("audit_write_failed", slog.String("err", err.Error()))
+		l.metrics.writeError(l.sink)
+		l.metrics.addDropped(l.sink, DropWriteFailed, 1)
+		return
+	}
+	l.metrics.addWritten(l.sink, 1)
+	l.metrics.setDepth(l.sink, len(l.events))
+}
+
+// Close drains the buffer. Events still in the spool stay on disk and are
+// replayed by the next AsyncLogger opened on the same spool directory.
 func (l *AsyncLogger) Close() error {
 	l.closeOnce.Do(func() {
 		close(l.events)
 	})
 	l.wg.Wait()
+	if l.spool != nil {
+		return l.spool.Close()
+	}
 	return nil
 }
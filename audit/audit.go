@@ -15,6 +15,13 @@ type Event struct {
 	Timestamp time.Time         `json:"timestamp"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
 	TraceID   string            `json:"trace_id,omitempty"`
+	Changes   []PatchOp         `json:"changes,omitempty"` // Set by Differ: RFC 6902 patch from OldValue to NewValue
+
+	// Set by Chain.Seal when the writer is configured with WithChain.
+	ChainID  string `json:"chain_id,omitempty"`
+	Seq      uint64 `json:"seq,omitempty"`
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"` // HMAC-SHA256 of the canonical encoding
 }
 
 // Logger defines where the audit log goes (Console, File, Kafka).
@@ -1,14 +1,113 @@
 package audit
 
+import "time"
+
 type Config struct {
-	Enabled bool `envconfig:"AUDIT_ENABLED" default:"true"`
+	Enabled bool `envconfig:"AUDIT_ENABLED" default:"true" desc:"Enable audit logging"`
+
+	BufferSize int `envconfig:"AUDIT_BUFFER_SIZE" default:"1024" desc:"Async audit buffer size (events)"`
+
+	BlockOnFull bool `envconfig:"AUDIT_BLOCK_ON_FULL" default:"false" desc:"Block callers when the buffer is full instead of dropping"`
+
+	SpoolDir string `envconfig:"AUDIT_SPOOL_DIR" desc:"Spill overflow events to this directory instead of dropping; empty disables"`
+
+	SpoolSegmentSize int64 `envconfig:"AUDIT_SPOOL_SEGMENT_SIZE" default:"67108864" desc:"Spool segment size in bytes"`
+
+	SpoolMaxBytes int64 `envconfig:"AUDIT_SPOOL_MAX_BYTES" default:"1073741824" desc:"Maximum spool size in bytes; beyond it events are dropped (0 = unlimited)"`
+
+	SpoolFsync string `envconfig:"AUDIT_SPOOL_FSYNC" default:"interval" validate:"oneof=always interval never" desc:"Spool fsync policy: always, interval or never"`
+
+	SpoolFsyncInterval time.Duration `envconfig:"AUDIT_SPOOL_FSYNC_INTERVAL" default:"1s" desc:"Spool fsync interval for the interval policy"`
+
+	MaxBodySize int64 `envconfig:"AUDIT_MAX_BODY_SIZE" default:"32768" desc:"Maximum request body bytes captured by HTTP auditing"`
+
+	ExcludePaths []string `envconfig:"AUDIT_EXCLUDE_PATHS" default:"/health,/metrics,/live,/ready" desc:"Paths never audited"`
+
+	MaskFields []string `envconfig:"AUDIT_MASK_FIELDS" default:"password,secret,token,api_key,authorization" desc:"Fields masked in old/new values: path[:redact|partial|hash]"`
+
+	MaskHashKey string `envconfig:"AUDIT_MASK_HASH_KEY" secret:"true" desc:"Key for the hash mask strategy"`
+
+	PrivilegedActions []string `envconfig:"AUDIT_PRIVILEGED_ACTIONS" desc:"Actions that require a change ticket (glob patterns, e.g. ADMIN_*)"`
 
-	BufferSize int `envconfig:"AUDIT_BUFFER_SIZE" default:"1024"`
+	Diff bool `envconfig:"AUDIT_DIFF" default:"true" desc:"Record a JSON Patch of old -> new values in each event"`
 
-	BlockOnFull bool `envconfig:"AUDIT_BLOCK_ON_FULL" default:"false"`
+	DiffDropSnapshots bool `envconfig:"AUDIT_DIFF_DROP_SNAPSHOTS" default:"false" desc:"Drop old/new values once the patch is recorded"`
 
-	MaxBodySize int64 `envconfig:"AUDIT_MAX_BODY_SIZE" default:"32768"`
+	DiffSkipUnchanged bool `envconfig:"AUDIT_DIFF_SKIP_UNCHANGED" default:"false" desc:"Drop update events whose old and new values are identical"`
+
+	HMACKey string `envconfig:"AUDIT_HMAC_KEY" secret:"true" desc:"Key for the tamper-evident hash chain; empty disables sealing"`
+
+	ChainID string `envconfig:"AUDIT_CHAIN_ID" desc:"Chain ID stamped on sealed events (default: hostname-pid-start)"`
+}
+
+// NewChain returns a new chain for one sink, or nil if no HMAC key is set.
+// Every sink needs its own chain: sinks sharing one would each see gaps in seq.
+// The chain ID is ChainID (or the per-process default) followed by "/<sink>".
+func (c Config) NewChain(sink string) *Chain {
+	if c.HMACKey == "" {
+		return nil
+	}
+	chain := NewChain([]byte(c.HMACKey), c.ChainID)
+	if sink != "" {
+		chain.id += "/" + sink
+	}
+	return chain
+}
+
+// NewMasker returns a Masker for MaskFields that also checks the mask tags of types.
+func (c Config) NewMasker(types ...interface{}) (*Masker, error) {
+	return NewMasker(c.MaskFields, []byte(c.MaskHashKey), types...)
+}
+
+// Processors returns the processors enabled by the config, in pipeline order:
+// enrichment (and the change ticket policy), masking, so nothing downstream
+// sees unmasked values, then diffing. types are passed to NewMasker.
+func (c Config) Processors(types ...interface{}) ([]Processor, error) {
+	enricher, err := NewEnricher(WithPrivilegedActions(c.PrivilegedActions...))
+	if err != nil {
+		return nil, err
+	}
+	masker, err := c.NewMasker(types...)
+	if err != nil {
+		return nil, err
+	}
+	procs := []Processor{enricher, masker}
+
+	if c.Diff {
+		var opts []DiffOption
+		if c.DiffDropSnapshots {
+			opts = append(opts, WithoutSnapshots())
+		}
+		if c.DiffSkipUnchanged {
+			opts = append(opts, SkipUnchanged())
+		}
+		procs = append(procs, NewDiffer(opts...))
+	}
+	return procs, nil
+}
+
+// Options returns the writer options the config asks for one sink: its processors
+// and, if AUDIT_HMAC_KEY is set, a new hash chain named after sink. Call it once
+// per sink constructor, with a distinct sink name, so each trail has its own chain.
+// types are passed to NewMasker.
+func (c Config) Options(sink string, types ...interface{}) ([]Option, error) {
+	procs, err := c.Processors(types...)
+	if err != nil {
+		return nil, err
+	}
+	return []Option{WithProcessors(procs...), WithChain(c.NewChain(sink))}, nil
+}
 
-	ExcludePaths []string `envconfig:"AUDIT_EXCLUDE_PATHS" default:"/health,/metrics,/live,/ready"`
-	MaskFields   []string
+// OpenSpool opens the spool described by the config, or returns nil if SpoolDir is empty.
+func (c Config) OpenSpool() (*Spool, error) {
+	if c.SpoolDir == "" {
+		return nil, nil
+	}
+	return OpenSpool(SpoolConfig{
+		Dir:           c.SpoolDir,
+		SegmentSize:   c.SpoolSegmentSize,
+		MaxBytes:      c.SpoolMaxBytes,
+		Fsync:         c.SpoolFsync,
+		FsyncInterval: c.SpoolFsyncInterval,
+	})
 }
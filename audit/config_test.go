@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestConfigOptionsGiveEachSinkItsOwnChain(t *testing.T) {
+	cfg := Config{HMACKey: "test-key", ChainID: "svc"}
+
+	var bufs [2]bytes.Buffer
+	var policies []SinkPolicy
+	var loggers []*AsyncLogger
+	for i, name := range []string{"primary", "archive"} {
+		opts, err := cfg.Options(name)
+		if err != nil {
+			t.Fatalf("Options: %v", err)
+		}
+		l := NewAsyncLogger(&bufs[i], 16, true, nil, opts...)
+		loggers = append(loggers, l)
+		policies = append(policies, SinkPolicy{Name: name, Logger: l, Required: true})
+	}
+	fan, err := NewFanout(nil, policies...)
+	if err != nil {
+		t.Fatalf("NewFanout: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := fan.Log(context.Background(), Event{Action: "UPDATE", Resource: "order"}); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+	}
+	for _, l := range loggers {
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	for i, name := range []string{"primary", "archive"} {
+		report, err := VerifyLog(&bufs[i], []byte(cfg.HMACKey))
+		if err != nil {
+			t.Fatalf("VerifyLog %s: %v", name, err)
+		}
+		if !report.Intact() || report.Records != 10 {
+			t.Errorf("%s trail: %d records, findings %v", name, report.Records, report.Findings)
+		}
+		if _, ok := report.Chains["svc/"+name]; !ok || len(report.Chains) != 1 {
+			t.Errorf("%s trail chains = %v, want only svc/%s", name, report.Chains, name)
+		}
+	}
+}
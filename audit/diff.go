@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PatchOp is one RFC 6902 JSON Patch operation. Old carries the previous
+// value for replace/remove; JSON Patch tools ignore it, auditors read it.
+type PatchOp struct {
+	Op    string      `json:"op"` // add | remove | replace
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+	Old   interface{} `json:"old,omitempty"`
+}
+
+// MarshalJSON always writes "value" for add and replace, even when it is null,
+// so the patch stays valid.
+func (p PatchOp) MarshalJSON() ([]byte, error) {
+	type plain PatchOp
+	if p.Op == "remove" || p.Value != nil {
+		return json.Marshal(plain(p))
+	}
+	return json.Marshal(struct {
+		plain
+		Value interface{} `json:"value"`
+	}{plain: plain(p)})
+}
+
+// Differ fills Event.Changes with a JSON Patch from OldValue to NewValue.
+// Run it after the Masker so the patch never contains unmasked values.
+type Differ struct {
+	dropSnapshots bool
+	skipUnchanged bool
+}
+
+// DiffOption configures a Differ.
+type DiffOption func(*Differ)
+
+// WithoutSnapshots clears OldValue and NewValue once the patch is computed.
+func WithoutSnapshots() DiffOption {
+	return func(d *Differ) { d.dropSnapshots = true }
+}
+
+// SkipUnchanged drops events whose old and new values are identical.
+func SkipUnchanged() DiffOption {
+	return func(d *Differ) { d.skipUnchanged = true }
+}
+
+func NewDiffer(opts ...DiffOption) *Differ {
+	d := &Differ{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Process computes the patch. Events without snapshots pass through untouched.
+func (d *Differ) Process(_ context.Context, event *Event) error {
+	if event.OldValue == nil && event.NewValue == nil {
+		return nil
+	}
+
+	changes, err := Diff(event.OldValue, event.NewValue)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 && d.skipUnchanged {
+		return ErrSkip
+	}
+
+	event.Changes = changes
+	if d.dropSnapshots {
+		event.OldValue, event.NewValue = nil, nil
+	}
+	return nil
+}
+
+// Diff returns the JSON Patch that turns old into new, comparing their JSON forms.
+// A nil old (create) is a single add at the root; a nil new (delete) a single remove.
+func Diff(oldV, newV interface{}) ([]PatchOp, error) {
+	oldG, err := toGeneric(oldV)
+	if err != nil {
+		return nil, err
+	}
+	newG, err := toGeneric(newV)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case oldG == nil && newG == nil:
+		return nil, nil
+	case oldG == nil:
+		return []PatchOp{{Op: "add", Path: "", Value: newG}}, nil
+	case newG == nil:
+		return []PatchOp{{Op: "remove", Path: "", Old: oldG}}, nil
+	}
+
+	var ops []PatchOp
+	diffValue("", oldG, newG, &ops)
+	return ops, nil
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to encode value for diff: %w", err)
+	}
+	g, err := decodeGeneric(data)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to encode value for diff: %w", err)
+	}
+	return g, nil
+}
+
+func diffValue(path string, oldV, newV interface{}, ops *[]PatchOp) {
+	switch o := oldV.(type) {
+	case map[string]interface{}:
+		if n, ok := newV.(map[string]interface{}); ok {
+			diffObject(path, o, n, ops)
+			return
+		}
+	case []interface{}:
+		if n, ok := newV.([]interface{}); ok {
+			diffArray(path, o, n, ops)
+			return
+		}
+	}
+	if !reflect.DeepEqual(oldV, newV) {
+		*ops = append(*ops, PatchOp{Op: "replace", Path: path, Value: newV, Old: oldV})
+	}
+}
+
+func diffObject(path string, oldM, newM map[string]interface{}, ops *[]PatchOp) {
+	keys := make([]string, 0, len(oldM)+len(newM))
+	for k := range oldM {
+		keys = append(keys, k)
+	}
+	for k := range newM {
+		if _, ok := oldM[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := path + "/" + escapePointer(k)
+		oldV, inOld := oldM[k]
+		newV, inNew := newM[k]
+		switch {
+		case !inNew:
+			*ops = append(*ops, PatchOp{Op: "remove", Path: child, Old: oldV})
+		case !inOld:
+			*ops = append(*ops, PatchOp{Op: "add", Path: child, Value: newV})
+		default:
+			diffValue(child, oldV, newV, ops)
+		}
+	}
+}
+
+// diffArray compares by index. Removals are emitted from the end so earlier
+// indexes stay valid when the patch is applied in order.
+func diffArray(path string, oldA, newA []interface{}, ops *[]PatchOp) {
+	common := min(len(oldA), len(newA))
+	for i := 0; i < common; i++ {
+		diffValue(path+"/"+strconv.Itoa(i), oldA[i], newA[i], ops)
+	}
+	for i := common; i < len(newA); i++ {
+		*ops = append(*ops, PatchOp{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: newA[i]})
+	}
+	for i := len(oldA) - 1; i >= common; i-- {
+		*ops = append(*ops, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i), Old: oldA[i]})
+	}
+}
+
+// escapePointer escapes a key for use in a JSON Pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+
+	"github.com/godamri/helix-fnd/pkg/contextx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrChangeTicketRequired rejects a privileged action logged without a change ticket.
+var ErrChangeTicketRequired = errors.New("audit: privileged action requires a change ticket")
+
+// Enricher fills fields the caller left empty from the request context.
+// Values set by the caller always win.
+//
+//	ActorID   <- contextx.GetAuthPrincipalID
+//	TraceID   <- active OTel span, else contextx.GetTraceID
+//	Metadata  <- request_id, source_service, entry_point, audit_reason,
+//	             change_ticket, session_id, decision_id
+type Enricher struct {
+	privileged []string
+}
+
+// EnrichOption configures an Enricher.
+type EnrichOption func(*Enricher)
+
+// WithPrivilegedActions requires a change ticket (contextx.WithChangeTicket, or a
+// "change_ticket" metadata entry) for matching actions. Patterns use path.Match
+// syntax, e.g. "DELETE_*" or "ADMIN_*".
+func WithPrivilegedActions(patterns ...string) EnrichOption {
+	return func(e *Enricher) { e.privileged = append(e.privileged, patterns...) }
+}
+
+// NewEnricher validates the privileged action patterns.
+func NewEnricher(opts ...EnrichOption) (*Enricher, error) {
+	e := &Enricher{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	for _, p := range e.privileged {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("audit: privileged action pattern %q: %w", p, err)
+		}
+	}
+	return e, nil
+}
+
+// WithContext wraps next so every event is enriched before it is written.
+func WithContext(next Logger, opts ...EnrichOption) (*Pipeline, error) {
+	e, err := NewEnricher(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return Pipe(next, e), nil
+}
+
+func (e *Enricher) Process(ctx context.Context, event *Event) error {
+	if event.ActorID == "" {
+		event.ActorID = contextx.GetAuthPrincipalID(ctx)
+	}
+	if event.TraceID == "" {
+		if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+			event.TraceID = sc.TraceID().String()
+		} else {
+			event.TraceID = known(contextx.GetTraceID(ctx))
+		}
+	}
+
+	// The map is shared with the caller: never write into theirs.
+	if event.Metadata == nil {
+		event.Metadata = make(map[string]string)
+	} else {
+		event.Metadata = maps.Clone(event.Metadata)
+	}
+	setMissing(event.Metadata, "request_id", contextx.GetRequestID(ctx))
+	setMissing(event.Metadata, "source_service", known(contextx.GetSourceService(ctx)))
+	setMissing(event.Metadata, "entry_point", known(contextx.GetEntryPoint(ctx)))
+	setMissing(event.Metadata, "audit_reason", contextx.GetAuditReason(ctx))
+	setMissing(event.Metadata, "change_ticket", contextx.GetChangeTicket(ctx))
+	setMissing(event.Metadata, "session_id", contextx.GetAuthSessionID(ctx))
+	setMissing(event.Metadata, "decision_id", contextx.GetAuthDecisionID(ctx))
+	if len(event.Metadata) == 0 {
+		event.Metadata = nil
+	}
+
+	if e.isPrivileged(event.Action) && event.Metadata["change_ticket"] == "" {
+		return fmt.Errorf("%w: %s", ErrChangeTicketRequired, event.Action)
+	}
+	return nil
+}
+
+func (e *Enricher) isPrivileged(action string) bool {
+	for _, p := range e.privileged {
+		if ok, _ := path.Match(p, action); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func setMissing(m map[string]string, key, value string) {
+	if value != "" && m[key] == "" {
+		m[key] = value
+	}
+}
+
+// known drops the "unknown" and "untriaged" fallbacks contextx returns for unset values.
+func known(v string) string {
+	if v == "unknown" || v == "untriaged" {
+		return ""
+	}
+	return v
+}
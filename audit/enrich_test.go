@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/godamri/helix-fnd/pkg/contextx"
+)
+
+func TestEnricherFillsMissingFields(t *testing.T) {
+	e, err := NewEnricher()
+	if err != nil {
+		t.Fatalf("NewEnricher: %v", err)
+	}
+	ctx := contextx.WithAuthPrincipalID(context.Background(), "user-1")
+	ctx = contextx.WithRequestID(ctx, "req-1")
+	ctx = contextx.WithTraceID(ctx, "trace-1")
+
+	event := Event{Action: "UPDATE", Metadata: map[string]string{"request_id": "explicit"}}
+	if err := e.Process(ctx, &event); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if event.ActorID != "user-1" || event.TraceID != "trace-1" {
+		t.Errorf("actor, trace = %q, %q", event.ActorID, event.TraceID)
+	}
+	if got := event.Metadata["request_id"]; got != "explicit" {
+		t.Errorf("request_id = %q, explicit values must win", got)
+	}
+}
+
+func TestEnricherDoesNotWriteCallerMetadata(t *testing.T) {
+	e, err := NewEnricher()
+	if err != nil {
+		t.Fatalf("NewEnricher: %v", err)
+	}
+	ctx := contextx.WithRequestID(context.Background(), "req-1")
+	shared := map[string]string{"tenant": "acme"}
+
+	// One metadata map reused across goroutines must stay untouched (run with -race).
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			event := Event{Action: "UPDATE", Metadata: shared}
+			if err := e.Process(ctx, &event); err != nil {
+				t.Errorf("Process: %v", err)
+			}
+			if event.Metadata["request_id"] != "req-1" || event.Metadata["tenant"] != "acme" {
+				t.Errorf("metadata = %v", event.Metadata)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(shared) != 1 {
+		t.Errorf("caller's metadata was modified: %v", shared)
+	}
+}
+
+func TestEnricherChangeTicketPolicy(t *testing.T) {
+	e, err := NewEnricher(WithPrivilegedActions("ADMIN_*"))
+	if err != nil {
+		t.Fatalf("NewEnricher: %v", err)
+	}
+
+	event := Event{Action: "ADMIN_DELETE_USER"}
+	if err := e.Process(context.Background(), &event); !errors.Is(err, ErrChangeTicketRequired) {
+		t.Errorf("privileged action without ticket: err = %v", err)
+	}
+
+	event = Event{Action: "ADMIN_DELETE_USER", Metadata: map[string]string{"change_ticket": "CHG-1"}}
+	if err := e.Process(context.Background(), &event); err != nil {
+		t.Errorf("privileged action with ticket: err = %v", err)
+	}
+
+	event = Event{Action: "UPDATE_ORDER"}
+	if err := e.Process(context.Background(), &event); err != nil {
+		t.Errorf("ordinary action: err = %v", err)
+	}
+}
+
+func TestEnricherLeavesTraceIDEmptyWithoutTrace(t *testing.T) {
+	e, err := NewEnricher()
+	if err != nil {
+		t.Fatalf("NewEnricher: %v", err)
+	}
+	event := Event{Action: "UPDATE"}
+	if err := e.Process(context.Background(), &event); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if event.TraceID != "" {
+		t.Errorf("TraceID = %q, want empty without a span or trace ID", event.TraceID)
+	}
+}
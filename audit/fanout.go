@@ -0,0 +1,268 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SinkPolicy configures one destination of a Fanout.
+type SinkPolicy struct {
+	Name   string
+	Logger Logger
+
+	// Required sinks make Log fail when they fail. Best-effort failures are only
+	// counted and reflected in Health.
+	Required bool
+
+	// BufferSize > 0 gives the sink its own queue and writer goroutine, so a slow
+	// sink does not hold up the others. Log then only reports enqueue failures
+	// for it; write errors show up in Health.
+	BufferSize  int
+	BlockOnFull bool
+
+	// Actions and Resources filter events with path.Match patterns ("DELETE_*", "Order:*").
+	// Empty means everything.
+	Actions   []string
+	Resources []string
+
+	// Metrics, if set, records the fan-out's own queue for this sink (depth,
+	// enqueue latency, drops) under Name. The sink reports its writes itself.
+	Metrics *Metrics
+}
+
+// SinkHealth is a snapshot of one sink's state.
+type SinkHealth struct {
+	Name                string    `json:"name"`
+	Required            bool      `json:"required"`
+	Healthy             bool      `json:"healthy"`
+	Written             uint64    `json:"written"`
+	Failed              uint64    `json:"failed"`
+	Dropped             uint64    `json:"dropped"`
+	ConsecutiveFailures uint64    `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastErrorAt         time.Time `json:"last_error_at,omitzero"`
+}
+
+type sink struct {
+	SinkPolicy
+	events chan sinkEvent
+
+	written     atomic.Uint64
+	failed      atomic.Uint64
+	dropped     atomic.Uint64
+	consecutive atomic.Uint64
+
+	mu        sync.Mutex
+	lastErr   string
+	lastErrAt time.Time
+}
+
+type sinkEvent struct {
+	ctx   context.Context
+	event Event
+}
+
+// Fanout writes every event to several sinks, each with its own policy.
+type Fanout struct {
+	sinks     []*sink
+	logger    *slog.Logger
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewFanout validates the policies and starts the queues of buffered sinks.
+// The Fanout owns the sinks: Close closes every sink that is an io.Closer.
+func NewFanout(logger *slog.Logger, policies ...SinkPolicy) (*Fanout, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	f := &Fanout{logger: logger.With("component", "audit_fanout")}
+
+	for i, p := range policies {
+		if p.Logger == nil {
+			return nil, fmt.Errorf("audit: sink %d (%s) has no logger", i, p.Name)
+		}
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("sink-%d", i)
+		}
+		for _, pattern := range append(append([]string{}, p.Actions...), p.Resources...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("audit: sink %s: pattern %q: %w", p.Name, pattern, err)
+			}
+		}
+
+		s := &sink{SinkPolicy: p}
+		if p.BufferSize > 0 {
+			s.events = make(chan sinkEvent, p.BufferSize)
+			f.wg.Add(1)
+			go f.drain(s)
+		}
+		f.sinks = append(f.sinks, s)
+	}
+	return f, nil
+}
+
+// Log sends the event to every matching sink. It returns an error only if a
+// required sink failed (or could not enqueue it); best-effort failures are absorbed.
+func (f *Fanout) Log(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	var errs []error
+	for _, s := range f.sinks {
+		if !s.matches(event) {
+			continue
+		}
+		// Sinks may run processors that edit metadata; give each its own map.
+		e := event
+		e.Metadata = maps.Clone(event.Metadata)
+
+		var err error
+		if s.events != nil {
+			err = s.enqueue(ctx, e)
+		} else {
+			err = s.write(ctx, e)
+		}
+		if err != nil && s.Required {
+			errs = append(errs, fmt.Errorf("audit: required sink %s: %w", s.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *sink) matches(e Event) bool {
+	return matchAny(s.Actions, e.Action) && matchAny(s.Resources, e.Resource)
+}
+
+func matchAny(patterns []string, v string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, v); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sink) enqueue(ctx context.Context, e Event) error {
+	start := time.Now()
+	defer func() {
+		s.Metrics.observeEnqueue(s.Name, start)
+		s.Metrics.setDepth(s.Name, len(s.events))
+	}()
+
+	item := sinkEvent{ctx: context.WithoutCancel(ctx), event: e}
+	if s.BlockOnFull {
+		select {
+		case s.events <- item:
+			return nil
+		case <-ctx.Done():
+			s.dropped.Add(1)
+			s.Metrics.addDropped(s.Name, DropCanceled, 1)
+			return ctx.Err()
+		}
+	}
+	select {
+	case s.events <- item:
+		return nil
+	default:
+		s.dropped.Add(1)
+		s.Metrics.addDropped(s.Name, DropBufferFull, 1)
+		return ErrAuditBufferFull
+	}
+}
+
+func (s *sink) write(ctx context.Context, e Event) error {
+	if err := s.Logger.Log(ctx, e); err != nil {
+		s.failed.Add(1)
+		s.consecutive.Add(1)
+		s.mu.Lock()
+		s.lastErr, s.lastErrAt = err.Error(), time.Now()
+		s.mu.Unlock()
+		return err
+	}
+	s.written.Add(1)
+	s.consecutive.Store(0)
+	return nil
+}
+
+func (f *Fanout) drain(s *sink) {
+	defer f.wg.Done()
+	for item := range s.events {
+		s.Metrics.setDepth(s.Name, len(s.events))
+		if err := s.write(item.ctx, item.event); err != nil {
+			f.logger.Warn("Audit sink write failed", "sink", s.Name, "required", s.Required, "error", err)
+		}
+	}
+}
+
+// Health returns a snapshot per sink. A sink is unhealthy while its most
+// recent write failed.
+func (f *Fanout) Health() []SinkHealth {
+	out := make([]SinkHealth, 0, len(f.sinks))
+	for _, s := range f.sinks {
+		s.mu.Lock()
+		h := SinkHealth{
+			Name:                s.Name,
+			Required:            s.Required,
+			Written:             s.written.Load(),
+			Failed:              s.failed.Load(),
+			Dropped:             s.dropped.Load(),
+			ConsecutiveFailures: s.consecutive.Load(),
+			LastError:           s.lastErr,
+			LastErrorAt:         s.lastErrAt,
+		}
+		s.mu.Unlock()
+		h.Healthy = h.ConsecutiveFailures == 0
+		out = append(out, h)
+	}
+	return out
+}
+
+// Check fails if any required sink is unhealthy. Use it as a readiness check:
+//
+//	checker.AddCheck("audit", fanout.Check)
+func (f *Fanout) Check(_ context.Context) error {
+	var errs []error
+	for _, h := range f.Health() {
+		if h.Required && !h.Healthy {
+			errs = append(errs, fmt.Errorf("audit sink %s: %s", h.Name, h.LastError))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close drains the sink queues, then closes every sink that is an io.Closer.
+func (f *Fanout) Close() error {
+	f.closeOnce.Do(func() {
+		for _, s := range f.sinks {
+			if s.events != nil {
+				close(s.events)
+			}
+		}
+		f.wg.Wait()
+
+		var errs []error
+		for _, s := range f.sinks {
+			if c, ok := s.Logger.(io.Closer); ok {
+				if err := c.Close(); err != nil {
+					errs = append(errs, fmt.Errorf("audit: close sink %s: %w", s.Name, err))
+				}
+			}
+		}
+		f.closeErr = errors.Join(errs...)
+	})
+	return f.closeErr
+}
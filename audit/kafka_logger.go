@@ -3,34 +3,149 @@ package audit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
+// Kafka delivery modes.
+const (
+	KafkaSync  = "sync"  // Log waits for the broker ack
+	KafkaAsync = "async" // Log returns once the record is buffered; failures go to the fallback
+)
+
+type KafkaConfig struct {
+	Brokers []string `envconfig:"AUDIT_KAFKA_BROKERS" desc:"Kafka brokers for the audit topic"`
+
+	Topic string `envconfig:"AUDIT_KAFKA_TOPIC" default:"system.audit.events" desc:"Audit topic"`
+
+	Mode string `envconfig:"AUDIT_KAFKA_MODE" default:"async" validate:"oneof=sync async" desc:"Delivery mode: sync waits for the ack, async buffers"`
+
+	Acks string `envconfig:"AUDIT_KAFKA_ACKS" default:"all" validate:"oneof=all leader none" desc:"Required acks: all, leader or none"`
+
+	Retries int `envconfig:"AUDIT_KAFKA_RETRIES" default:"10" validate:"min=0" desc:"Produce retries per record before falling back"`
+
+	DeliveryTimeout time.Duration `envconfig:"AUDIT_KAFKA_DELIVERY_TIMEOUT" default:"30s" desc:"Maximum time a record may spend in retries"`
+
+	CloseTimeout time.Duration `envconfig:"AUDIT_KAFKA_CLOSE_TIMEOUT" default:"10s" desc:"Flush budget on Close"`
+
+	FallbackPath string `envconfig:"AUDIT_KAFKA_FALLBACK_PATH" desc:"File that receives records Kafka could not accept (JSON lines)"`
+}
+
 type KafkaLogger struct {
-	client *kgo.Client
-	topic  string
+	client       *kgo.Client
+	topic        string
+	sync         bool
+	closeTimeout time.Duration
+	logger       *slog.Logger
+	procs        []Processor
+	metrics      *Metrics
+	sink         string
+
+	// mu keeps seal order and produce order identical.
+	mu     sync.Mutex
+	chain  *Chain
+	closed bool
+
+	fallbackMu sync.Mutex
+	fallback   io.Writer
+	ownedFile  *os.File
+
+	pending    atomic.Int64 // produced, not yet acked or failed
+	fellBack   atomic.Uint64
+	lost       atomic.Uint64 // failed and no fallback could take it
+	closeOnce  sync.Once
+	closeError error
+}
+
+// NewKafkaLogger connects with defaults: async delivery, acks=all, no fallback.
+func NewKafkaLogger(brokers []string, topic string, opts ...Option) (*KafkaLogger, error) {
+	return NewKafkaLoggerWithConfig(KafkaConfig{
+		Brokers:         brokers,
+		Topic:           topic,
+		Mode:            KafkaAsync,
+		Acks:            "all",
+		Retries:         10,
+		DeliveryTimeout: 30 * time.Second,
+		CloseTimeout:    10 * time.Second,
+	}, nil, nil, opts...)
 }
 
-func NewKafkaLogger(brokers []string, topic string) (*KafkaLogger, error) {
-	if topic == "" {
-		topic = "system.audit.events"
+// NewKafkaLoggerWithConfig connects to Kafka. Records that still fail after the retries
+// are written as JSON lines to fallback (or to cfg.FallbackPath if fallback is nil).
+//
+// Records are keyed by Event.Resource, so each resource's history lands on one
+// partition, in order. Events without a resource are spread across partitions.
+func NewKafkaLoggerWithConfig(cfg KafkaConfig, fallback io.Writer, logger *slog.Logger, opts ...Option) (*KafkaLogger, error) {
+	if cfg.Topic == "" {
+		cfg.Topic = "system.audit.events"
+	}
+	if cfg.CloseTimeout <= 0 {
+		cfg.CloseTimeout = 10 * time.Second
+	}
+	if logger == nil {
+		logger = slog.Default()
 	}
 
 	// Franz-go options for Audit Logging
 	// We prioritize throughput and compression over absolute latency here.
-	opts := []kgo.Opt{
-		kgo.SeedBrokers(brokers...),
+	kopts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
 		kgo.ProducerBatchCompression(kgo.SnappyCompression()), // Good balance
 		kgo.AllowAutoTopicCreation(),                          // Helpful for audit topics
-		kgo.RecordPartitioner(kgo.RoundRobinPartitioner()),    // Even distribution since we have no keys
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)),  // Same resource, same partition; unkeyed records stay sticky
+		kgo.RecordRetries(cfg.Retries),
+	}
+	if cfg.DeliveryTimeout > 0 {
+		kopts = append(kopts, kgo.RecordDeliveryTimeout(cfg.DeliveryTimeout))
+	}
+	switch cfg.Acks {
+	case "", "all":
+		kopts = append(kopts, kgo.RequiredAcks(kgo.AllISRAcks()))
+	case "leader":
+		kopts = append(kopts, kgo.RequiredAcks(kgo.LeaderAck()), kgo.DisableIdempotentWrite())
+	case "none":
+		kopts = append(kopts, kgo.RequiredAcks(kgo.NoAck()), kgo.DisableIdempotentWrite())
+	default:
+		return nil, fmt.Errorf("audit: unknown kafka acks %q", cfg.Acks)
+	}
+	if cfg.Mode != "" && cfg.Mode != KafkaSync && cfg.Mode != KafkaAsync {
+		return nil, fmt.Errorf("audit: unknown kafka mode %q", cfg.Mode)
 	}
 
-	client, err := kgo.NewClient(opts...)
+	o := applyOptions(opts)
+	k := &KafkaLogger{
+		topic:        cfg.Topic,
+		sync:         cfg.Mode == KafkaSync,
+		closeTimeout: cfg.CloseTimeout,
+		logger:       logger.With("component", "audit_kafka"),
+		procs:        o.procs,
+		chain:        o.chain,
+		metrics:      o.metrics,
+		sink:         o.sink,
+		fallback:     fallback,
+	}
+	if k.sink == "" {
+		k.sink = "kafka"
+	}
+	if fallback == nil && cfg.FallbackPath != "" {
+		f, err := os.OpenFile(cfg.FallbackPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("audit: failed to open kafka fallback file: %w", err)
+		}
+		k.fallback, k.ownedFile = f, f
+	}
+
+	client, err := kgo.NewClient(kopts...)
 	if err != nil {
+		k.closeFallback()
 		return nil, fmt.Errorf("audit: failed to create franz-go client: %w", err)
 	}
 
@@ -39,39 +154,158 @@ func NewKafkaLogger(brokers []string, topic string) (*KafkaLogger, error) {
 	defer cancel()
 	if err := client.Ping(ctx); err != nil {
 		client.Close()
+		k.closeFallback()
 		return nil, fmt.Errorf("audit: failed to connect to brokers: %w", err)
 	}
 
-	return &KafkaLogger{
-		client: client,
-		topic:  topic,
-	}, nil
+	k.client = client
+	return k, nil
 }
 
+// Log produces the event. In sync mode it returns once the broker acked it, or
+// once it was written to the fallback; the error is non-nil only if both failed.
+// In async mode it returns once the record is buffered and failures are handled
+// in the background the same way.
 func (k *KafkaLogger) Log(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if skip, err := process(ctx, k.procs, &event); skip || err != nil {
+		return err
+	}
+
+	start := time.Now()
+	defer k.metrics.observeEnqueue(k.sink, start)
+
+	k.mu.Lock()
+	if k.closed {
+		k.mu.Unlock()
+		return errors.New("audit: kafka logger closed")
+	}
+	if k.chain != nil {
+		if err := k.chain.Seal(&event); err != nil {
+			k.mu.Unlock()
+			return err
+		}
+	}
 	payload, err := json.Marshal(event)
 	if err != nil {
+		k.mu.Unlock()
 		return fmt.Errorf("audit: marshal failed: %w", err)
 	}
+	record := &kgo.Record{Topic: k.topic, Value: payload}
+	if event.Resource != "" {
+		record.Key = []byte(event.Resource)
+	}
 
-	record := &kgo.Record{
-		Topic: k.topic,
-		Value: payload,
-		// No Key: We want round-robin distribution for max throughput
+	if k.sync {
+		// Enqueue under mu so records reach the partition in seal order; only
+		// the wait for the ack happens outside it.
+		done := make(chan error, 1)
+		k.client.Produce(ctx, record, func(_ *kgo.Record, err error) { done <- err })
+		k.mu.Unlock()
+
+		if err := <-done; err != nil {
+			return k.deliveryFailed(record, err)
+		}
+		k.metrics.addWritten(k.sink, 1)
+		return nil
 	}
 
-	// ASYNC PRODUCE
-	k.client.Produce(ctx, record, func(r *kgo.Record, err error) {
+	// ASYNC PRODUCE. The request context usually ends before the ack; keep its values only.
+	k.metrics.setDepth(k.sink, int(k.pending.Add(1)))
+	k.client.Produce(context.WithoutCancel(ctx), record, func(r *kgo.Record, err error) {
+		defer func() { k.metrics.setDepth(k.sink, int(k.pending.Add(-1))) }()
 		if err != nil {
-			// Simple, direct callback handling.
-			log.Printf("audit: failed to send log to kafka: %v\n", err)
+			_ = k.deliveryFailed(r, err)
+			return
 		}
+		k.metrics.addWritten(k.sink, 1)
 	})
+	k.mu.Unlock()
+	return nil
+}
 
+// deliveryFailed hands a record Kafka would not take to the fallback.
+// A lost sealed record is logged with its chain_id and seq, so the gap
+// VerifyLog reports can be matched to it.
+func (k *KafkaLogger) deliveryFailed(r *kgo.Record, cause error) error {
+	k.metrics.writeError(k.sink)
+	if k.fallback == nil {
+		k.lost.Add(1)
+		k.metrics.kafkaFailure(k.sink, "lost")
+		k.metrics.addDropped(k.sink, DropWriteFailed, 1)
+		k.logger.Error("Audit event lost: kafka delivery failed and no fallback is configured", sealAttrs(r, "error", cause)...)
+		return fmt.Errorf("audit: kafka delivery failed: %w", cause)
+	}
+
+	k.fallbackMu.Lock()
+	_, err := k.fallback.Write(append(r.Value[:len(r.Value):len(r.Value)], '\n'))
+	k.fallbackMu.Unlock()
+	if err != nil {
+		k.lost.Add(1)
+		k.metrics.kafkaFailure(k.sink, "lost")
+		k.metrics.addDropped(k.sink, DropWriteFailed, 1)
+		k.logger.Error("Audit event lost: kafka and fallback both failed", sealAttrs(r, "error", cause, "fallback_error", err)...)
+		return fmt.Errorf("audit: kafka delivery failed (%v) and fallback write failed: %w", cause, err)
+	}
+
+	k.fellBack.Add(1)
+	k.metrics.kafkaFailure(k.sink, "fallback")
+	k.logger.Warn("Audit event written to fallback", "error", cause)
 	return nil
 }
 
+// sealAttrs appends the chain_id and seq of a sealed record to attrs.
+func sealAttrs(r *kgo.Record, attrs ...interface{}) []interface{} {
+	var sealed struct {
+		ChainID string `json:"chain_id"`
+		Seq     uint64 `json:"seq"`
+	}
+	if json.Unmarshal(r.Value, &sealed) == nil && sealed.ChainID != "" {
+		attrs = append(attrs, "chain_id", sealed.ChainID, "seq", sealed.Seq)
+	}
+	return attrs
+}
+
+// Flush waits until every buffered record is acked or failed over, or ctx ends.
+func (k *KafkaLogger) Flush(ctx context.Context) error {
+	return k.client.Flush(ctx)
+}
+
+// Close flushes within CloseTimeout, then closes the client. Records still
+// buffered at the deadline are failed over to the fallback. The error reports
+// how many events did not reach Kafka. Log fails once Close has started.
 func (k *KafkaLogger) Close() error {
-	k.client.Close() // Flushes buffers and closes
-	return nil
+	k.closeOnce.Do(func() {
+		k.mu.Lock()
+		k.closed = true
+		k.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), k.closeTimeout)
+		defer cancel()
+
+		var errs []error
+		if err := k.client.Flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("audit: kafka flush incomplete, %d events undelivered: %w", k.pending.Load(), err))
+		}
+		k.client.Close() // Fails whatever is left, which runs the fallback
+
+		if n := k.lost.Load(); n > 0 {
+			errs = append(errs, fmt.Errorf("audit: %d events lost (no fallback or fallback failed)", n))
+		}
+		if n := k.fellBack.Load(); n > 0 {
+			k.logger.Warn("Audit events written to fallback instead of kafka", "count", n)
+		}
+		errs = append(errs, k.closeFallback())
+		k.closeError = errors.Join(errs...)
+	})
+	return k.closeError
+}
+
+func (k *KafkaLogger) closeFallback() error {
+	if k.ownedFile == nil {
+		return nil
+	}
+	return k.ownedFile.Close()
 }
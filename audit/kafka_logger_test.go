@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestKafkaDeliveryFailedLogsLostSeq(t *testing.T) {
+	var logs bytes.Buffer
+	k := &KafkaLogger{logger: slog.New(slog.NewTextHandler(&logs, nil)), sink: "kafka"}
+
+	payload, _ := json.Marshal(Event{Action: "UPDATE", ChainID: "svc/kafka", Seq: 42})
+	err := k.deliveryFailed(&kgo.Record{Value: payload}, errors.New("broker down"))
+	if err == nil {
+		t.Fatal("deliveryFailed without a fallback returned nil")
+	}
+	if k.lost.Load() != 1 {
+		t.Errorf("lost = %d, want 1", k.lost.Load())
+	}
+	if out := logs.String(); !strings.Contains(out, "chain_id=svc/kafka") || !strings.Contains(out, "seq=42") {
+		t.Errorf("log does not name the lost record: %s", out)
+	}
+}
+
+func TestKafkaLogAfterCloseFails(t *testing.T) {
+	k := &KafkaLogger{logger: slog.Default(), closed: true}
+	if err := k.Log(context.Background(), Event{Action: "UPDATE"}); err == nil {
+		t.Error("Log after Close returned nil")
+	}
+}
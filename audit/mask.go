@@ -0,0 +1,342 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Masked replaces redacted values.
+const Masked = "[REDACTED]"
+
+// Mask strategies.
+const (
+	MaskRedact  = "redact"  // "[REDACTED]"
+	MaskPartial = "partial" // "************4242", keeps the last 4 characters
+	MaskHash    = "hash"    // "hmac:<hex>", keyed so equal values stay correlatable
+)
+
+const partialVisible = 4
+
+// maskRule matches a path of JSON object keys. Arrays are transparent:
+// "cards.number" matches every element of the "cards" array.
+type maskRule struct {
+	segments []string // "*" matches any single key
+	anyDepth bool     // bare name: matches the key wherever it appears
+	strategy string
+}
+
+// Masker hides sensitive fields in Event.OldValue and Event.NewValue.
+//
+// Fields are selected by patterns ("path[:strategy]") and by struct tags:
+//
+//	password                  any "password" key, at any depth, redacted
+//	customer.national_id:hash exact path from the root, keyed hash
+//	cards.*.pan:partial       "*" matches one key
+//
+//	type Card struct {
+//		PAN string `json:"pan" audit:"mask,partial"`
+//	}
+//
+// Key matching is case-insensitive.
+type Masker struct {
+	rules   []maskRule
+	hashKey []byte
+
+	tagRules sync.Map // reflect.Type -> []maskRule
+}
+
+// NewMasker parses patterns and checks the struct tags of types, given as sample
+// values (e.g. Card{}). hashKey is required if any pattern or tag uses the hash
+// strategy. Tags of types not listed are checked when first masked, and a bad
+// tag fails that Mask call.
+func NewMasker(patterns []string, hashKey []byte, types ...interface{}) (*Masker, error) {
+	m := &Masker{hashKey: hashKey}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		path, strategy, _ := strings.Cut(p, ":")
+		rule, err := newMaskRule(path, strategy)
+		if err != nil {
+			return nil, fmt.Errorf("audit: mask pattern %q: %w", p, err)
+		}
+		if rule.strategy == MaskHash && len(hashKey) == 0 {
+			return nil, fmt.Errorf("audit: mask pattern %q: hash strategy needs a key", p)
+		}
+		m.rules = append(m.rules, rule)
+	}
+	for _, v := range types {
+		if v == nil {
+			continue
+		}
+		if _, err := m.rulesFor(reflect.TypeOf(v)); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func maskStrategy(strategy string) (string, error) {
+	switch strategy {
+	case "":
+		return MaskRedact, nil
+	case MaskRedact, MaskPartial, MaskHash:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown strategy %q", strategy)
+	}
+}
+
+func newMaskRule(path, strategy string) (maskRule, error) {
+	strategy, err := maskStrategy(strategy)
+	if err != nil {
+		return maskRule{}, err
+	}
+	segments := strings.Split(path, ".")
+	for _, s := range segments {
+		if s == "" {
+			return maskRule{}, errors.New("empty path segment")
+		}
+	}
+	return maskRule{segments: segments, anyDepth: len(segments) == 1, strategy: strategy}, nil
+}
+
+// Process masks OldValue and NewValue in place.
+func (m *Masker) Process(_ context.Context, event *Event) error {
+	var err error
+	if event.OldValue, err = m.Mask(event.OldValue); err != nil {
+		return err
+	}
+	if event.NewValue, err = m.Mask(event.NewValue); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Mask returns a masked copy of v in its generic JSON form (maps, slices, scalars).
+func (m *Masker) Mask(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	rules := m.rules
+	tagged, err := m.rulesFor(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if len(tagged) > 0 {
+		// tagged is shared through the cache: clip it so append copies.
+		rules = append(tagged[:len(tagged):len(tagged)], rules...) // tags win over patterns
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to encode value for masking: %w", err)
+	}
+	generic, err := decodeGeneric(data)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to encode value for masking: %w", err)
+	}
+	if len(rules) == 0 {
+		return generic, nil
+	}
+	return m.walk(generic, nil, rules)
+}
+
+func decodeGeneric(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+func (m *Masker) walk(v interface{}, path []string, rules []maskRule) (interface{}, error) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			childPath := append(path[:len(path):len(path)], k)
+			if rule, ok := matchRule(rules, childPath); ok {
+				masked, err := m.apply(child, rule.strategy)
+				if err != nil {
+					return nil, err
+				}
+				node[k] = masked
+				continue
+			}
+			masked, err := m.walk(child, childPath, rules)
+			if err != nil {
+				return nil, err
+			}
+			node[k] = masked
+		}
+	case []interface{}:
+		for i, child := range node {
+			masked, err := m.walk(child, path, rules)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = masked
+		}
+	}
+	return v, nil
+}
+
+func matchRule(rules []maskRule, path []string) (maskRule, bool) {
+	for _, r := range rules {
+		if r.anyDepth {
+			if strings.EqualFold(r.segments[0], path[len(path)-1]) {
+				return r, true
+			}
+			continue
+		}
+		if len(r.segments) != len(path) {
+			continue
+		}
+		matched := true
+		for i, s := range r.segments {
+			if s != "*" && !strings.EqualFold(s, path[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return r, true
+		}
+	}
+	return maskRule{}, false
+}
+
+func (m *Masker) apply(v interface{}, strategy string) (interface{}, error) {
+	if v == nil {
+		return nil, nil // nothing to hide; keeps "was unset" visible
+	}
+
+	var s string
+	switch val := v.(type) {
+	case string:
+		s = val
+	case json.Number:
+		s = val.String()
+	default:
+		data, _ := json.Marshal(val)
+		s = string(data)
+	}
+
+	switch strategy {
+	case MaskPartial:
+		r := []rune(s)
+		if len(r) <= partialVisible {
+			return strings.Repeat("*", len(r)), nil
+		}
+		return strings.Repeat("*", len(r)-partialVisible) + string(r[len(r)-partialVisible:]), nil
+	case MaskHash:
+		if len(m.hashKey) == 0 {
+			return nil, errors.New("audit: hash masking needs a key")
+		}
+		mac := hmac.New(sha256.New, m.hashKey)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)), nil
+	default:
+		return Masked, nil
+	}
+}
+
+// rulesFor turns `audit:"mask[,strategy]"` tags of t into exact-path rules.
+// Valid rule sets are cached per type.
+func (m *Masker) rulesFor(t reflect.Type) ([]maskRule, error) {
+	if cached, ok := m.tagRules.Load(t); ok {
+		return cached.([]maskRule), nil
+	}
+	var rules []maskRule
+	if err := collectTagRules(t, nil, map[reflect.Type]bool{}, &rules); err != nil {
+		return nil, fmt.Errorf("audit: mask tags of %s: %w", t, err)
+	}
+	for _, r := range rules {
+		if r.strategy == MaskHash && len(m.hashKey) == 0 {
+			return nil, fmt.Errorf("audit: mask tags of %s: field %s: hash strategy needs a key", t, strings.Join(r.segments, "."))
+		}
+	}
+	m.tagRules.Store(t, rules)
+	return rules, nil
+}
+
+func collectTagRules(t reflect.Type, path []string, visiting map[reflect.Type]bool, rules *[]maskRule) error {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			t = t.Elem()
+			continue
+		case reflect.Map:
+			return collectTagRules(t.Elem(), append(path[:len(path):len(path)], "*"), visiting, rules)
+		}
+		break
+	}
+	if t.Kind() != reflect.Struct || visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name, embedded := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		if embedded {
+			if err := collectTagRules(sf.Type, path, visiting, rules); err != nil {
+				return err
+			}
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], name)
+
+		if tag, ok := sf.Tag.Lookup("audit"); ok {
+			kind, strategy, _ := strings.Cut(tag, ",")
+			if kind == "mask" {
+				strategy, err := maskStrategy(strategy)
+				if err != nil {
+					return fmt.Errorf("field %s: %w", strings.Join(fieldPath, "."), err)
+				}
+				*rules = append(*rules, maskRule{segments: fieldPath, strategy: strategy})
+				continue
+			}
+		}
+		if err := collectTagRules(sf.Type, fieldPath, visiting, rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonName mirrors encoding/json: untagged anonymous structs are flattened.
+func jsonName(sf reflect.StructField) (name string, embedded bool) {
+	tag := sf.Tag.Get("json")
+	name, _, _ = strings.Cut(tag, ",")
+	if name == "" && sf.Anonymous {
+		t := sf.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", true
+		}
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	return name, false
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMaskerStrategies(t *testing.T) {
+	m, err := NewMasker([]string{"password", "card:partial", "name:partial", "email:hash"}, []byte("k"))
+	if err != nil {
+		t.Fatalf("NewMasker: %v", err)
+	}
+
+	got, err := m.Mask(map[string]interface{}{
+		"password": "hunter2",
+		"card":     "4111111111111111",
+		"name":     "José Müller ñ",
+		"email":    "a@example.com",
+		"plain":    "kept",
+	})
+	if err != nil {
+		t.Fatalf("Mask: %v", err)
+	}
+	out := got.(map[string]interface{})
+
+	tests := map[string]string{
+		"password": Masked,
+		"card":     "************1111",
+		"name":     "*********er ñ", // 13 runes, last 4 kept
+		"plain":    "kept",
+	}
+	for k, want := range tests {
+		if out[k] != want {
+			t.Errorf("%s = %q, want %q", k, out[k], want)
+		}
+	}
+	if s, _ := out["name"].(string); !utf8.ValidString(s) {
+		t.Errorf("partial mask produced invalid UTF-8: %q", s)
+	}
+	if s, _ := out["email"].(string); len(s) != len("hmac:")+64 || s[:5] != "hmac:" {
+		t.Errorf("email = %q, want hmac:<hex>", s)
+	}
+}
+
+func TestMaskerProcessLeavesCallerValues(t *testing.T) {
+	m, err := NewMasker([]string{"password"}, nil)
+	if err != nil {
+		t.Fatalf("NewMasker: %v", err)
+	}
+	orig := map[string]interface{}{"password": "hunter2"}
+	event := Event{NewValue: orig}
+	if err := m.Process(context.Background(), &event); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if orig["password"] != "hunter2" {
+		t.Errorf("caller's value was masked in place")
+	}
+}
+
+type taggedAccount struct {
+	PIN   string `json:"pin" audit:"mask"`
+	Card  string `json:"card" audit:"mask,partial"`
+	Phone string `json:"phone" audit:"mask,partial"`
+	Token string `json:"token"`
+}
+
+// Three tagged rules leave spare capacity in the cached slice, so appending
+// the pattern rules in place would race between concurrent calls.
+func TestMaskerTaggedConcurrent(t *testing.T) {
+	m, err := NewMasker([]string{"token"}, nil)
+	if err != nil {
+		t.Fatalf("NewMasker: %v", err)
+	}
+	v := taggedAccount{PIN: "1234", Card: "4111111111111111", Phone: "5550001234", Token: "t"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				got, err := m.Mask(v)
+				if err != nil {
+					t.Errorf("Mask: %v", err)
+					return
+				}
+				out := got.(map[string]interface{})
+				if out["pin"] != Masked || out["card"] != "************1111" || out["token"] != Masked {
+					t.Errorf("Mask = %v", out)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewMaskerRejectsBadTags(t *testing.T) {
+	type unknown struct {
+		PAN string `json:"pan" audit:"mask,shred"`
+	}
+	type hashed struct {
+		Card struct {
+			PAN string `json:"pan" audit:"mask,hash"`
+		} `json:"card"`
+	}
+
+	if _, err := NewMasker(nil, nil, unknown{}); err == nil || !strings.Contains(err.Error(), `unknown strategy "shred"`) {
+		t.Errorf("unknown strategy: err = %v", err)
+	}
+	if _, err := NewMasker(nil, nil, &hashed{}); err == nil || !strings.Contains(err.Error(), "card.pan: hash strategy needs a key") {
+		t.Errorf("hash without key: err = %v", err)
+	}
+	if _, err := NewMasker(nil, []byte("k"), hashed{}); err != nil {
+		t.Errorf("hash with key: %v", err)
+	}
+
+	// Types not checked up front fail when first masked.
+	m, err := NewMasker(nil, nil)
+	if err != nil {
+		t.Fatalf("NewMasker: %v", err)
+	}
+	if _, err := m.Mask(unknown{PAN: "4111111111111111"}); err == nil {
+		t.Error("Mask accepted an unknown tag strategy")
+	}
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Drop reasons used in audit_events_dropped_total.
+const (
+	DropBufferFull   = "buffer_full"
+	DropCanceled     = "context_canceled"
+	DropSpoolFull    = "spool_full"
+	DropSealFailed   = "seal_failed"
+	DropWriteFailed  = "write_failed"
+	DropEncodeFailed = "encode_failed"
+)
+
+// Metrics instruments audit sinks. Create one per registry and share it;
+// each sink reports under its own "sink" label (see WithMetrics).
+// A nil *Metrics records nothing.
+type Metrics struct {
+	queueDepth     *prometheus.GaugeVec
+	enqueueLatency *prometheus.HistogramVec
+	written        *prometheus.CounterVec
+	writeErrors    *prometheus.CounterVec
+	dropped        *prometheus.CounterVec
+	spilled        *prometheus.CounterVec
+	kafkaFailures  *prometheus.CounterVec
+}
+
+// NewMetrics registers the audit metrics with reg (prometheus.DefaultRegisterer if nil).
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	f := promauto.With(reg)
+
+	return &Metrics{
+		queueDepth: f.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "audit_queue_depth",
+			Help: "Events buffered in memory and not yet written, by sink.",
+		}, []string{"sink"}),
+		enqueueLatency: f.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "audit_enqueue_duration_seconds",
+			Help:    "Time Log spent handing an event to a sink. High values mean callers are blocked on a full buffer.",
+			Buckets: []float64{.00001, .0001, .001, .01, .05, .1, .5, 1, 5},
+		}, []string{"sink"}),
+		written: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "audit_events_written_total",
+			Help: "Events written successfully, by sink.",
+		}, []string{"sink"}),
+		writeErrors: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "audit_write_errors_total",
+			Help: "Failed write attempts, by sink. Retried writes may count more than once.",
+		}, []string{"sink"}),
+		dropped: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "audit_events_dropped_total",
+			Help: "Events lost, by sink and reason. Alert on any increase.",
+		}, []string{"sink", "reason"}),
+		spilled: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "audit_events_spilled_total",
+			Help: "Events spilled to the disk spool because the buffer was full, by sink.",
+		}, []string{"sink"}),
+		kafkaFailures: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "audit_kafka_delivery_failures_total",
+			Help: "Kafka deliveries that failed after retries, by outcome (fallback, lost).",
+		}, []string{"sink", "outcome"}),
+	}
+}
+
+func (m *Metrics) setDepth(sink string, n int) {
+	if m != nil {
+		m.queueDepth.WithLabelValues(sink).Set(float64(n))
+	}
+}
+
+func (m *Metrics) observeEnqueue(sink string, start time.Time) {
+	if m != nil {
+		m.enqueueLatency.WithLabelValues(sink).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) addWritten(sink string, n int) {
+	if m != nil {
+		m.written.WithLabelValues(sink).Add(float64(n))
+	}
+}
+
+func (m *Metrics) writeError(sink string) {
+	if m != nil {
+		m.writeErrors.WithLabelValues(sink).Inc()
+	}
+}
+
+func (m *Metrics) addDropped(sink, reason string, n int) {
+	if m != nil {
+		m.dropped.WithLabelValues(sink, reason).Add(float64(n))
+	}
+}
+
+func (m *Metrics) spill(sink string) {
+	if m != nil {
+		m.spilled.WithLabelValues(sink).Inc()
+	}
+}
+
+func (m *Metrics) kafkaFailure(sink, outcome string) {
+	if m != nil {
+		m.kafkaFailures.WithLabelValues(sink, outcome).Inc()
+	}
+}
@@ -0,0 +1,45 @@
+package audit
+
+// Option configures an audit writer (AsyncLogger, KafkaLogger, PostgresLogger).
+// Config.Options returns the ones the audit config asks for.
+type Option func(*options)
+
+type options struct {
+	procs   []Processor
+	chain   *Chain
+	spool   *Spool
+	metrics *Metrics
+	sink    string
+}
+
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithProcessors runs procs (enrichment, masking, diffing) in Log, on the
+// caller's goroutine, before the event is buffered or written. See Pipe.
+func WithProcessors(procs ...Processor) Option {
+	return func(o *options) { o.procs = append(o.procs, procs...) }
+}
+
+// WithChain seals every event into a tamper-evident hash chain before it is written.
+// A nil chain leaves events unsealed.
+func WithChain(c *Chain) Option {
+	return func(o *options) { o.chain = c }
+}
+
+// WithSpool makes AsyncLogger spill to disk instead of blocking or dropping when
+// its buffer is full. It takes precedence over blockOnFull. The logger owns the
+// spool from then on and closes it on Close. Ignored by KafkaLogger.
+func WithSpool(s *Spool) Option {
+	return func(o *options) { o.spool = s }
+}
+
+// WithMetrics reports the writer's queue, writes and losses under the given sink label.
+func WithMetrics(m *Metrics, sink string) Option {
+	return func(o *options) { o.metrics, o.sink = m, sink }
+}
@@ -0,0 +1,271 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultOutboxTable is used by outboxes and relays without a table configured.
+const DefaultOutboxTable = "audit_outbox"
+
+// Outbox writes events transactionally. OutboxRelay forwards them to a sink.
+type Outbox struct {
+	table string
+	procs []Processor
+}
+
+// NewOutbox runs procs (enrich, mask, diff) before each insert, so unmasked
+// values never reach the table.
+func NewOutbox(table string, procs ...Processor) (*Outbox, error) {
+	if table == "" {
+		table = DefaultOutboxTable
+	}
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("audit: invalid table name %q", table)
+	}
+	return &Outbox{table: table, procs: procs}, nil
+}
+
+// LogTx inserts the event inside tx, so it commits or rolls back with the
+// business change. A processor returning ErrSkip drops it silently.
+func (o *Outbox) LogTx(ctx context.Context, tx pgx.Tx, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if skip, err := process(ctx, o.procs, &event); skip || err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("audit: marshal failed: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (payload) VALUES ($1)`, o.table), payload); err != nil {
+		return fmt.Errorf("audit: outbox insert failed: %w", err)
+	}
+	return nil
+}
+
+type OutboxConfig struct {
+	Table string `envconfig:"AUDIT_OUTBOX_TABLE" default:"audit_outbox" desc:"Transactional audit outbox table"`
+
+	BatchSize int `envconfig:"AUDIT_OUTBOX_BATCH_SIZE" default:"100" desc:"Rows forwarded per relay transaction"`
+
+	PollInterval time.Duration `envconfig:"AUDIT_OUTBOX_POLL_INTERVAL" default:"1s" desc:"Relay poll interval when the outbox is empty"`
+
+	Retention time.Duration `envconfig:"AUDIT_OUTBOX_RETENTION" default:"24h" desc:"How long delivered rows are kept before cleanup"`
+}
+
+// OutboxRelay forwards committed outbox rows to a sink and marks them delivered.
+//
+// Rows are claimed with FOR UPDATE SKIP LOCKED, so several replicas can relay the
+// same table. Delivery is at-least-once: a crash between the sink accepting an event
+// and the commit resends it. "Delivered" means the sink's Log returned nil; pair it
+// with a sync KafkaLogger if that must mean acknowledged by Kafka.
+type OutboxRelay struct {
+	pool   *pgxpool.Pool
+	sink   Logger
+	cfg    OutboxConfig
+	logger *slog.Logger
+
+	lastCleanup time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewOutboxRelay creates the outbox table if missing.
+func NewOutboxRelay(ctx context.Context, pool *pgxpool.Pool, sink Logger, cfg OutboxConfig, logger *slog.Logger) (*OutboxRelay, error) {
+	if cfg.Table == "" {
+		cfg.Table = DefaultOutboxTable
+	}
+	if !tableName.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("audit: invalid table name %q", cfg.Table)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	r := &OutboxRelay{
+		pool:   pool,
+		sink:   sink,
+		cfg:    cfg,
+		logger: logger.With("component", "audit_outbox_relay"),
+	}
+	if err := r.migrate(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *OutboxRelay) migrate(ctx context.Context) error {
+	t := r.cfg.Table
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id           bigserial   PRIMARY KEY,
+	created_at   timestamptz NOT NULL DEFAULT now(),
+	payload      jsonb       NOT NULL,
+	delivered_at timestamptz
+)`, t),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_pending_idx ON %s (id) WHERE delivered_at IS NULL`, t, t),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_delivered_idx ON %s (delivered_at) WHERE delivered_at IS NOT NULL`, t, t),
+	}
+	for _, stmt := range stmts {
+		if _, err := r.pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("audit: failed to migrate %s: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// Start relays until Stop is called.
+func (r *OutboxRelay) Start(ctx context.Context) error {
+	if r.done != nil {
+		return errors.New("audit: outbox relay already started")
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go r.run(ctx)
+	return nil
+}
+
+// Stop ends the relay loop after the current batch.
+func (r *OutboxRelay) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *OutboxRelay) run(ctx context.Context) {
+	defer close(r.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("Audit outbox relay failed", "error", err)
+		}
+		r.cleanup(ctx)
+
+		// A full batch means more is probably waiting: go again right away.
+		if err == nil && n == r.cfg.BatchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.cfg.PollInterval)
+		}
+	}
+}
+
+// RelayOnce forwards one batch and returns how many rows were delivered.
+// It stops at the first sink failure so the rest of the batch is retried.
+//
+// Rows are read by id, but delivery order is best effort: ids are assigned at
+// insert, not commit, so a slow transaction can land after higher ids were
+// relayed, and concurrent relays skip each other's locked rows.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("audit: outbox begin failed: %w", err)
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }() // no-op after commit
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`SELECT id, payload FROM %s
+WHERE delivered_at IS NULL
+ORDER BY id
+LIMIT %d
+FOR UPDATE SKIP LOCKED`, r.cfg.Table, r.cfg.BatchSize))
+	if err != nil {
+		return 0, fmt.Errorf("audit: outbox claim failed: %w", err)
+	}
+
+	type pending struct {
+		id      int64
+		payload []byte
+	}
+	var batch []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("audit: outbox scan failed: %w", err)
+		}
+		batch = append(batch, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("audit: outbox claim failed: %w", err)
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	delivered := make([]int64, 0, len(batch))
+	var sinkErr error
+	for _, p := range batch {
+		var event Event
+		if err := json.Unmarshal(p.payload, &event); err != nil {
+			// Cannot ever succeed; mark it so it does not block the outbox forever.
+			r.logger.Error("AUDIT_LOG_CRITICAL_FAILURE", "reason", "outbox_payload_corrupt", "id", p.id, "error", err)
+			delivered = append(delivered, p.id)
+			continue
+		}
+		if err := r.sink.Log(ctx, event); err != nil {
+			sinkErr = fmt.Errorf("audit: outbox sink failed at id %d: %w", p.id, err)
+			break
+		}
+		delivered = append(delivered, p.id)
+	}
+
+	if len(delivered) > 0 {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE %s SET delivered_at = now() WHERE id = ANY($1)`, r.cfg.Table), delivered); err != nil {
+			return 0, fmt.Errorf("audit: outbox mark failed: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return 0, fmt.Errorf("audit: outbox commit failed: %w", err)
+		}
+	}
+	return len(delivered), sinkErr
+}
+
+// cleanup deletes delivered rows past retention, at most once a minute.
+func (r *OutboxRelay) cleanup(ctx context.Context) {
+	if r.cfg.Retention <= 0 || time.Since(r.lastCleanup) < time.Minute {
+		return
+	}
+	r.lastCleanup = time.Now()
+
+	_, err := r.pool.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE delivered_at < $1`, r.cfg.Table), time.Now().Add(-r.cfg.Retention))
+	if err != nil && ctx.Err() == nil {
+		r.logger.Warn("Audit outbox cleanup failed", "error", err)
+	}
+}
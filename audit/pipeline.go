@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrSkip is returned by a Processor to drop an event without failing the caller.
+var ErrSkip = errors.New("audit: event skipped")
+
+// Processor transforms an event before it reaches a sink (masking, diffing, enrichment).
+type Processor interface {
+	Process(ctx context.Context, event *Event) error
+}
+
+// ProcessorFunc adapts a function to Processor.
+type ProcessorFunc func(ctx context.Context, event *Event) error
+
+func (f ProcessorFunc) Process(ctx context.Context, event *Event) error { return f(ctx, event) }
+
+// Pipeline runs processors in order, then hands the event to the next Logger.
+// It runs on the caller's goroutine, so nothing unprocessed is ever buffered.
+type Pipeline struct {
+	next  Logger
+	procs []Processor
+}
+
+// Pipe wraps next with processors.
+//
+//	auditLog := audit.Pipe(audit.NewAsyncLogger(w, 1024, true, logger), masker)
+func Pipe(next Logger, procs ...Processor) *Pipeline {
+	return &Pipeline{next: next, procs: procs}
+}
+
+// Log fails closed: if a processor errors, the event is not written,
+// since writing it half-processed could leak what a masker was meant to hide.
+func (p *Pipeline) Log(ctx context.Context, event Event) error {
+	if skip, err := process(ctx, p.procs, &event); skip || err != nil {
+		return err
+	}
+	return p.next.Log(ctx, event)
+}
+
+// process runs procs in order. skip is true when the event must not be
+// written: a processor returned ErrSkip (err is nil) or failed (err is set).
+func process(ctx context.Context, procs []Processor, event *Event) (skip bool, err error) {
+	for _, proc := range procs {
+		if err := proc.Process(ctx, event); err != nil {
+			if errors.Is(err, ErrSkip) {
+				return true, nil
+			}
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// Close closes the next Logger if it is an io.Closer.
+func (p *Pipeline) Close() error {
+	if c, ok := p.next.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresConfig struct {
+	Table string `envconfig:"AUDIT_PG_TABLE" default:"audit_events" desc:"Partitioned audit table"`
+
+	BufferSize int `envconfig:"AUDIT_PG_BUFFER_SIZE" default:"10000" desc:"Events buffered before Log blocks"`
+
+	BatchSize int `envconfig:"AUDIT_PG_BATCH_SIZE" default:"500" desc:"Events per COPY"`
+
+	FlushInterval time.Duration `envconfig:"AUDIT_PG_FLUSH_INTERVAL" default:"1s" desc:"Maximum time an event waits for a batch"`
+
+	PartitionsAhead int `envconfig:"AUDIT_PG_PARTITIONS_AHEAD" default:"2" desc:"Monthly partitions created ahead of the current month"`
+
+	WriteTimeout time.Duration `envconfig:"AUDIT_PG_WRITE_TIMEOUT" default:"30s" desc:"Timeout per COPY attempt"`
+
+	FallbackPath string `envconfig:"AUDIT_PG_FALLBACK_PATH" desc:"File that receives batches PostgreSQL would not accept (JSON lines)"`
+}
+
+var (
+	tableName = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,40}$`)
+
+	postgresColumns = []string{
+		"id", "occurred_at", "actor_id", "action", "resource", "trace_id",
+		"old_value", "new_value", "changes", "metadata",
+		"chain_id", "seq", "prev_hash", "hash",
+	}
+)
+
+const postgresWriteAttempts = 3
+
+// PostgresLogger writes events to a table partitioned by month on occurred_at,
+// in batches using COPY. Partitions are created on startup and on demand.
+//
+// Log only enqueues; a single writer batches, seals (WithChain) and copies.
+// A batch that still fails after retries goes, sealed, to cfg.FallbackPath.
+type PostgresLogger struct {
+	pool    *pgxpool.Pool
+	cfg     PostgresConfig
+	table   string
+	logger  *slog.Logger
+	procs   []Processor
+	chain   *Chain
+	metrics *Metrics
+	sink    string
+
+	fallback *os.File // writer goroutine only
+
+	events    chan Event
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+
+	partitions map[string]bool // months known to exist; writer goroutine only
+}
+
+// NewPostgresLogger creates the table, indexes and upcoming partitions if missing,
+// then starts the writer. pool is usually from database.NewPostgres.
+func NewPostgresLogger(ctx context.Context, pool *pgxpool.Pool, cfg PostgresConfig, logger *slog.Logger, opts ...Option) (*PostgresLogger, error) {
+	if cfg.Table == "" {
+		cfg.Table = "audit_events"
+	}
+	if !tableName.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("audit: invalid table name %q", cfg.Table)
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 30 * time.Second
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	o := applyOptions(opts)
+	p := &PostgresLogger{
+		pool:       pool,
+		cfg:        cfg,
+		table:      cfg.Table,
+		logger:     logger.With("component", "audit_postgres"),
+		procs:      o.procs,
+		chain:      o.chain,
+		metrics:    o.metrics,
+		sink:       o.sink,
+		events:     make(chan Event, cfg.BufferSize),
+		partitions: make(map[string]bool),
+	}
+
+	if p.sink == "" {
+		p.sink = "postgres"
+	}
+
+	if err := p.migrate(ctx); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for i := 0; i <= cfg.PartitionsAhead; i++ {
+		if err := p.ensurePartition(ctx, now.AddDate(0, i, 0)); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.FallbackPath != "" {
+		f, err := os.OpenFile(cfg.FallbackPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("audit: failed to open postgres fallback file: %w", err)
+		}
+		p.fallback = f
+	}
+
+	p.wg.Add(1)
+	go p.worker()
+	return p, nil
+}
+
+func (p *PostgresLogger) migrate(ctx context.Context) error {
+	t := p.table
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id          uuid        NOT NULL,
+	occurred_at timestamptz NOT NULL,
+	actor_id    text        NOT NULL,
+	action      text        NOT NULL,
+	resource    text        NOT NULL,
+	trace_id    text,
+	old_value   jsonb,
+	new_value   jsonb,
+	changes     jsonb,
+	metadata    jsonb,
+	chain_id    text,
+	seq         bigint,
+	prev_hash   text,
+	hash        text,
+	PRIMARY KEY (id, occurred_at)
+) PARTITION BY RANGE (occurred_at)`, t),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_actor_idx ON %s (actor_id, occurred_at DESC)`, t, t),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_resource_idx ON %s (resource, occurred_at DESC)`, t, t),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_occurred_idx ON %s (occurred_at DESC)`, t, t),
+	}
+	for _, stmt := range stmts {
+		if _, err := p.pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("audit: failed to migrate %s: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// ensurePartition creates the monthly partition containing ts, e.g. audit_events_y2024m05.
+func (p *PostgresLogger) ensurePartition(ctx context.Context, ts time.Time) error {
+	from := time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC)
+	name := fmt.Sprintf("%s_y%04dm%02d", p.table, from.Year(), from.Month())
+	if p.partitions[name] {
+		return nil
+	}
+
+	stmt := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+		name, p.table, from.Format(time.RFC3339), from.AddDate(0, 1, 0).Format(time.RFC3339))
+	if _, err := p.pool.Exec(ctx, stmt); err != nil {
+		return fmt.Errorf("audit: failed to create partition %s: %w", name, err)
+	}
+	p.partitions[name] = true
+	return nil
+}
+
+// Log enqueues the event, blocking while the buffer is full until ctx ends.
+func (p *PostgresLogger) Log(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if skip, err := process(ctx, p.procs, &event); skip || err != nil {
+		return err
+	}
+
+	start := time.Now()
+	defer func() {
+		p.metrics.observeEnqueue(p.sink, start)
+		p.metrics.setDepth(p.sink, len(p.events))
+	}()
+
+	select {
+	case p.events <- event:
+		return nil
+	case <-ctx.Done():
+		p.metrics.addDropped(p.sink, DropCanceled, 1)
+		return ctx.Err()
+	}
+}
+
+func (p *PostgresLogger) worker() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, p.cfg.BatchSize)
+	for {
+		select {
+		case event, ok := <-p.events:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= p.cfg.BatchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush seals and copies a batch, retrying transient failures. A batch that
+// still fails goes to the fallback file; either way the chain_id/seq range is
+// logged, so a gap found by VerifyLog can be matched to it.
+func (p *PostgresLogger) flush(batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	sealed := batch[:0] // rows[i] is sealed[i]
+	rows := make([][]interface{}, 0, len(batch))
+	for i := range batch {
+		row, err := p.row(&batch[i])
+		if err != nil {
+			p.logger.Error("audit_encode_failed", "error", err, "action", batch[i].Action)
+			p.metrics.addDropped(p.sink, DropEncodeFailed, 1)
+			continue
+		}
+		sealed = append(sealed, batch[i])
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	var err error
+	for attempt := 1; attempt <= postgresWriteAttempts; attempt++ {
+		if err = p.copy(sealed, rows); err == nil {
+			p.metrics.addWritten(p.sink, len(rows))
+			p.metrics.setDepth(p.sink, len(p.events))
+			return
+		}
+		p.metrics.writeError(p.sink)
+		p.logger.Warn("Audit batch write failed", "error", err, "attempt", attempt, "events", len(rows))
+		time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+	}
+
+	attrs := []interface{}{"error", err, "events", len(sealed)}
+	if first, last := sealed[0], sealed[len(sealed)-1]; first.ChainID != "" {
+		attrs = append(attrs, "chain_id", first.ChainID, "first_seq", first.Seq, "last_seq", last.Seq)
+	}
+	if p.fallback != nil {
+		ferr := p.writeFallback(sealed)
+		if ferr == nil {
+			p.logger.Warn("Audit batch written to fallback", attrs...)
+			return
+		}
+		attrs = append(attrs, "fallback_error", ferr)
+	}
+	p.metrics.addDropped(p.sink, DropWriteFailed, len(sealed))
+	p.logger.Error("AUDIT_LOG_CRITICAL_FAILURE", append(attrs, "reason", "postgres_copy_failed")...)
+}
+
+// writeFallback appends the sealed events as JSON lines and syncs the file.
+func (p *PostgresLogger) writeFallback(events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return fmt.Errorf("audit: failed to encode fallback event: %w", err)
+		}
+	}
+	if _, err := p.fallback.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("audit: failed to write postgres fallback: %w", err)
+	}
+	if err := p.fallback.Sync(); err != nil {
+		return fmt.Errorf("audit: failed to sync postgres fallback: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresLogger) copy(batch []Event, rows [][]interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.WriteTimeout)
+	defer cancel()
+
+	for _, e := range batch {
+		if err := p.ensurePartition(ctx, e.Timestamp.UTC()); err != nil {
+			return err
+		}
+	}
+	_, err := p.pool.CopyFrom(ctx, pgx.Identifier{p.table}, postgresColumns, pgx.CopyFromRows(rows))
+	return err
+}
+
+// row encodes e, then seals it. Sealing last means an event that cannot be
+// encoded never takes a seq, so it cannot leave a gap in the chain.
+func (p *PostgresLogger) row(e *Event) ([]interface{}, error) {
+	id, err := uuid.NewV7() // time-ordered: inserts stay at the end of the primary key index
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, 0, 4)
+	for _, v := range []interface{}{e.OldValue, e.NewValue, e.Changes, e.Metadata} {
+		j, err := jsonColumn(v)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, j)
+	}
+
+	if p.chain != nil {
+		if err := p.chain.Seal(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return []interface{}{
+		id, e.Timestamp, e.ActorID, e.Action, e.Resource, nullable(e.TraceID),
+		values[0], values[1], values[2], values[3],
+		nullable(e.ChainID), nullableSeq(e.Seq), nullable(e.PrevHash), nullable(e.Hash),
+	}, nil
+}
+
+func jsonColumn(v interface{}) ([]byte, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case []PatchOp:
+		if len(val) == 0 {
+			return nil, nil
+		}
+	case map[string]string:
+		if len(val) == 0 {
+			return nil, nil
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to encode column: %w", err)
+	}
+	return data, nil
+}
+
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func nullableSeq(seq uint64) *int64 {
+	if seq == 0 {
+		return nil
+	}
+	n := int64(seq)
+	return &n
+}
+
+// Close writes what is buffered and stops the writer.
+func (p *PostgresLogger) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.events)
+		p.wg.Wait()
+		if p.fallback != nil {
+			err = p.fallback.Close()
+		}
+	})
+	p.wg.Wait()
+	return err
+}
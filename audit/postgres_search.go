@@ -0,0 +1,203 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by the store.
+var ErrInvalidCursor = errors.New("audit: invalid cursor")
+
+// Query filters stored events. Zero values are ignored.
+type Query struct {
+	ActorID        string
+	Action         string
+	ResourcePrefix string
+	TraceID        string
+	From           time.Time // inclusive
+	To             time.Time // exclusive
+	Limit          int
+	Cursor         string
+}
+
+// Record is a stored event.
+type Record struct {
+	ID string `json:"id"`
+	Event
+}
+
+// Page is one page of results, newest first.
+type Page struct {
+	Records    []Record
+	NextCursor string // empty on the last page
+}
+
+// Searcher queries stored events.
+type Searcher interface {
+	Search(ctx context.Context, q Query) (*Page, error)
+}
+
+// PostgresSearcher queries the table written by PostgresLogger.
+// Pagination is keyset-based on (occurred_at, id), so deep pages stay cheap
+// and concurrent inserts never shift results between pages.
+type PostgresSearcher struct {
+	pool  *pgxpool.Pool
+	table string
+}
+
+func NewPostgresSearcher(pool *pgxpool.Pool, table string) (*PostgresSearcher, error) {
+	if table == "" {
+		table = "audit_events"
+	}
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("audit: invalid table name %q", table)
+	}
+	return &PostgresSearcher{pool: pool, table: table}, nil
+}
+
+type searchCursor struct {
+	OccurredAt time.Time `json:"t"`
+	ID         string    `json:"id"`
+}
+
+func (s *PostgresSearcher) Search(ctx context.Context, q Query) (*Page, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	var where []string
+	var args []interface{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if q.ActorID != "" {
+		add("actor_id = ?", q.ActorID)
+	}
+	if q.Action != "" {
+		add("action = ?", q.Action)
+	}
+	if q.ResourcePrefix != "" {
+		add(`resource LIKE ? ESCAPE '\'`, escapeLike(q.ResourcePrefix)+"%")
+	}
+	if q.TraceID != "" {
+		add("trace_id = ?", q.TraceID)
+	}
+	if !q.From.IsZero() {
+		add("occurred_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		add("occurred_at < ?", q.To)
+	}
+	if q.Cursor != "" {
+		cur, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, cur.OccurredAt, cur.ID)
+		where = append(where, fmt.Sprintf("(occurred_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	sql := fmt.Sprintf(`SELECT id, occurred_at, actor_id, action, resource, trace_id,
+	old_value, new_value, changes, metadata, chain_id, seq, prev_hash, hash
+FROM %s`, s.table)
+	if len(where) > 0 {
+		sql += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	// One extra row tells us whether there is a next page.
+	sql += fmt.Sprintf("\nORDER BY occurred_at DESC, id DESC\nLIMIT %d", limit+1)
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit: search failed: %w", err)
+	}
+	defer rows.Close()
+
+	page := &Page{Records: make([]Record, 0, limit)}
+	for rows.Next() {
+		var (
+			rec                              Record
+			id                               uuid.UUID
+			traceID, chainID, prevHash, hash *string
+			seq                              *int64
+			oldValue, newValue               []byte
+		)
+		if err := rows.Scan(&id, &rec.Timestamp, &rec.ActorID, &rec.Action, &rec.Resource, &traceID,
+			&oldValue, &newValue, &rec.Changes, &rec.Metadata, &chainID, &seq, &prevHash, &hash); err != nil {
+			return nil, fmt.Errorf("audit: search scan failed: %w", err)
+		}
+		rec.ID = id.String()
+		rec.TraceID = deref(traceID)
+		rec.ChainID, rec.PrevHash, rec.Hash = deref(chainID), deref(prevHash), deref(hash)
+		if seq != nil {
+			rec.Seq = uint64(*seq)
+		}
+		if oldValue != nil {
+			rec.OldValue = json.RawMessage(oldValue)
+		}
+		if newValue != nil {
+			rec.NewValue = json.RawMessage(newValue)
+		}
+		page.Records = append(page.Records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: search failed: %w", err)
+	}
+
+	if len(page.Records) > limit {
+		page.Records = page.Records[:limit]
+		last := page.Records[limit-1]
+		page.NextCursor = encodeCursor(searchCursor{OccurredAt: last.Timestamp, ID: last.ID})
+	}
+	return page, nil
+}
+
+func encodeCursor(c searchCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (searchCursor, error) {
+	var c searchCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,438 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const manifestFile = "manifest.json"
+
+// RotatingFileConfig configures a RotatingFile. Load it like KafkaConfig.
+type RotatingFileConfig struct {
+	Dir string `envconfig:"AUDIT_FILE_DIR" default:"/var/log/audit" desc:"Directory for audit log segments"`
+
+	Name string `envconfig:"AUDIT_FILE_NAME" default:"audit" desc:"Segment file name prefix"`
+
+	MaxSize int64 `envconfig:"AUDIT_FILE_MAX_SIZE" default:"104857600" desc:"Rotate after this many bytes"`
+
+	RotateEvery time.Duration `envconfig:"AUDIT_FILE_ROTATE_EVERY" default:"24h" desc:"Rotate segments older than this (0 disables)"`
+
+	Compress bool `envconfig:"AUDIT_FILE_COMPRESS" default:"true" desc:"Gzip rotated segments"`
+
+	MaxBackups int `envconfig:"AUDIT_FILE_MAX_BACKUPS" default:"0" desc:"Rotated segments kept (0 = unlimited)"`
+
+	MaxAge time.Duration `envconfig:"AUDIT_FILE_MAX_AGE" default:"0" desc:"Delete rotated segments older than this (0 = keep)"`
+}
+
+// SegmentInfo describes one rotated segment in the manifest.
+type SegmentInfo struct {
+	File      string    `json:"file"`
+	Events    int       `json:"events"`
+	First     time.Time `json:"first,omitzero"`
+	Last      time.Time `json:"last,omitzero"`
+	Bytes     int64     `json:"bytes"` // size on disk, after compression
+	SHA256    string    `json:"sha256"`
+	RotatedAt time.Time `json:"rotated_at"`
+}
+
+// Manifest lists rotated segments, oldest first.
+type Manifest struct {
+	Segments []SegmentInfo `json:"segments"`
+}
+
+// RotatingFile is an io.WriteCloser for AsyncLogger that rotates by size and age,
+// gzips rotated segments, enforces retention and keeps manifest.json up to date.
+//
+// Each Write must be whole JSON lines, which is what AsyncLogger produces.
+// Rotation (fsync, compression, pruning) runs inline on the writer goroutine,
+// or on a timer once a segment reaches RotateEvery without being written to.
+type RotatingFile struct {
+	cfg    RotatingFileConfig
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time // when the segment got its first event
+	timer  *time.Timer
+	events int
+	first  time.Time
+	last   time.Time
+	closed bool
+}
+
+// NewRotatingFile opens (or resumes) the active segment in cfg.Dir.
+// Rotation problems that do not stop writing are reported to logger.
+func NewRotatingFile(cfg RotatingFileConfig, logger *slog.Logger) (*RotatingFile, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("audit: file dir is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "audit"
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 100 << 20
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("audit: failed to create log dir: %w", err)
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	f := &RotatingFile{cfg: cfg, logger: logger.With("component", "audit_file")}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	// A resumed segment may already be due: then the timer rotates it right away.
+	f.schedule(time.Until(f.opened.Add(f.cfg.RotateEvery)))
+	return f, nil
+}
+
+func (f *RotatingFile) activePath() string {
+	return filepath.Join(f.cfg.Dir, f.cfg.Name+".jsonl")
+}
+
+// open opens the active segment, rescanning it if a previous process left one
+// behind. A resumed segment dates from its oldest event, not its mtime, which
+// every append moves.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.activePath(), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("audit: failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("audit: failed to stat log file: %w", err)
+	}
+
+	f.file, f.size = file, info.Size()
+	f.events, f.first, f.last = 0, time.Time{}, time.Time{}
+	f.opened = time.Now()
+	if f.size > 0 {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			f.count(scanner.Bytes())
+		}
+		switch {
+		case !f.first.IsZero():
+			f.opened = f.first
+		case info.ModTime().Before(f.opened):
+			f.opened = info.ModTime() // no timestamps to go by; the best bound left
+		}
+	}
+	return nil
+}
+
+// schedule arms the age rotation timer to fire after d.
+func (f *RotatingFile) schedule(d time.Duration) {
+	if f.cfg.RotateEvery <= 0 || f.closed {
+		return
+	}
+	if f.timer == nil {
+		f.timer = time.AfterFunc(d, f.rotateDue)
+		return
+	}
+	f.timer.Reset(d)
+}
+
+// rotateDue rotates a segment that reached RotateEvery with no write to do it.
+func (f *RotatingFile) rotateDue() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed || f.file == nil {
+		return // Write reopens the segment, which rearms the timer
+	}
+	if f.size == 0 {
+		f.opened = time.Now()
+		f.schedule(f.cfg.RotateEvery)
+		return
+	}
+	if age := time.Since(f.opened); age < f.cfg.RotateEvery {
+		f.schedule(f.cfg.RotateEvery - age)
+		return
+	}
+
+	if err := f.rotate(); err != nil {
+		f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "open"))
+		return
+	}
+	if f.file != nil && time.Since(f.opened) >= f.cfg.RotateEvery {
+		f.schedule(time.Minute) // rotation was skipped (e.g. fsync failed): retry later
+	}
+}
+
+// count updates the segment stats for one JSON line.
+func (f *RotatingFile) count(line []byte) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	f.events++
+
+	var e struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if json.Unmarshal(line, &e) != nil || e.Timestamp.IsZero() {
+		return
+	}
+	if f.first.IsZero() || e.Timestamp.Before(f.first) {
+		f.first = e.Timestamp
+	}
+	if e.Timestamp.After(f.last) {
+		f.last = e.Timestamp
+	}
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		// A previous rotation could not reopen the active segment: retry.
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+		f.schedule(time.Until(f.opened.Add(f.cfg.RotateEvery)))
+	}
+	if f.size == 0 {
+		f.opened = time.Now() // an empty segment ages from its first event
+	}
+	if f.size > 0 && (f.size+int64(len(p)) > f.cfg.MaxSize ||
+		(f.cfg.RotateEvery > 0 && time.Since(f.opened) >= f.cfg.RotateEvery)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	for _, line := range bytes.Split(p[:n], []byte{'\n'}) {
+		f.count(line)
+	}
+	return n, err
+}
+
+// Rotate closes the active segment and starts a new one, even if it is below the limits.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil || f.size == 0 {
+		return nil
+	}
+	return f.rotate()
+}
+
+// rotate seals the active segment and opens a new one. It only fails if no
+// active segment could be opened; any other problem is logged and writing goes
+// on, since a failed rotation must not stop the audit trail.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Sync(); err != nil {
+		// Keep appending to the current segment; the next write retries.
+		f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "sync"))
+		return nil
+	}
+	if err := f.file.Close(); err != nil {
+		f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "close"))
+	}
+	f.file = nil
+
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%s.jsonl", f.cfg.Name, now.Format("20060102T150405.000000000Z"))
+	rotated := filepath.Join(f.cfg.Dir, name)
+	seg := SegmentInfo{File: name, Events: f.events, First: f.first, Last: f.last, RotatedAt: now}
+
+	if err := os.Rename(f.activePath(), rotated); err != nil {
+		f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "rename"))
+		return f.open()
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.schedule(f.cfg.RotateEvery)
+	if err := syncDir(f.cfg.Dir); err != nil {
+		f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "dir_sync"), slog.String("segment", name))
+	}
+
+	// The new segment is open: from here on, failures only affect the old one.
+	if f.cfg.Compress {
+		gz, err := compressFile(rotated)
+		if err != nil {
+			f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "compress"), slog.String("segment", name))
+		} else {
+			seg.File = filepath.Base(gz)
+			rotated = gz
+		}
+	}
+	if err := fileDigest(rotated, &seg); err != nil {
+		f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "hash"), slog.String("segment", seg.File))
+	}
+	if err := f.updateManifest(seg); err != nil {
+		f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "manifest"), slog.String("segment", seg.File))
+	}
+	// Makes the .gz, the removed .jsonl, pruning and the manifest rename durable.
+	if err := syncDir(f.cfg.Dir); err != nil {
+		f.logger.Error("audit_file_rotation_failed", slog.String("err", err.Error()), slog.String("step", "dir_sync"), slog.String("segment", seg.File))
+	}
+	return nil
+}
+
+// syncDir fsyncs a directory, so renames, creations and removals in it survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("audit: failed to sync log dir: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("audit: failed to sync log dir: %w", err)
+	}
+	return nil
+}
+
+// compressFile gzips path to path.gz, fsyncs it and removes the original.
+func compressFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("audit: failed to compress segment: %w", err)
+	}
+	defer src.Close()
+
+	dstPath := path + ".gz"
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return "", fmt.Errorf("audit: failed to compress segment: %w", err)
+	}
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dstPath)
+		return "", fmt.Errorf("audit: failed to compress segment: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("audit: failed to remove compressed segment: %w", err)
+	}
+	return dstPath, nil
+}
+
+func fileDigest(path string, seg *SegmentInfo) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("audit: failed to hash segment: %w", err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, file)
+	if err != nil {
+		return fmt.Errorf("audit: failed to hash segment: %w", err)
+	}
+	seg.Bytes = n
+	seg.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// ReadManifest loads the manifest in dir. A missing manifest is empty.
+func ReadManifest(dir string) (*Manifest, error) {
+	m := &Manifest{}
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("audit: failed to parse manifest: %w", err)
+	}
+	return m, nil
+}
+
+// updateManifest appends seg, applies retention and rewrites the manifest atomically.
+func (f *RotatingFile) updateManifest(seg SegmentInfo) error {
+	m, err := ReadManifest(f.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	m.Segments = append(m.Segments, seg)
+	sort.SliceStable(m.Segments, func(i, j int) bool { return m.Segments[i].RotatedAt.Before(m.Segments[j].RotatedAt) })
+
+	kept := m.Segments[:0]
+	for i, s := range m.Segments {
+		expired := f.cfg.MaxAge > 0 && time.Since(s.RotatedAt) > f.cfg.MaxAge
+		excess := f.cfg.MaxBackups > 0 && len(m.Segments)-i > f.cfg.MaxBackups
+		if expired || excess {
+			err := os.Remove(filepath.Join(f.cfg.Dir, s.File))
+			if err == nil || errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			// Keep it listed so the next rotation retries.
+			f.logger.Error("audit_file_prune_failed", slog.String("err", err.Error()), slog.String("segment", s.File))
+		}
+		kept = append(kept, s)
+	}
+	m.Segments = kept
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("audit: failed to encode manifest: %w", err)
+	}
+	tmp := filepath.Join(f.cfg.Dir, manifestFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("audit: failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(f.cfg.Dir, manifestFile)); err != nil {
+		return fmt.Errorf("audit: failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// Close syncs and closes the active segment without rotating it;
+// the next process resumes appending to it.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	if f.file == nil {
+		return nil
+	}
+	return errors.Join(f.file.Sync(), f.file.Close())
+}
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func waitForSegments(t *testing.T, dir string, n int) *Manifest {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		m, err := ReadManifest(dir)
+		if err != nil {
+			t.Fatalf("ReadManifest: %v", err)
+		}
+		if len(m.Segments) >= n {
+			return m
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d segments rotated, want %d", len(m.Segments), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRotatingFileRotatesIdleSegment(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewRotatingFile(RotatingFileConfig{Dir: dir, RotateEvery: 100 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("NewRotatingFile: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "{\"timestamp\":%q}\n", time.Now().Format(time.RFC3339Nano)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	m := waitForSegments(t, dir, 1)
+	if m.Segments[0].Events != 1 {
+		t.Errorf("segment has %d events, want 1", m.Segments[0].Events)
+	}
+}
+
+// A segment resumed after a restart is as old as its first event, however
+// recently it was appended to.
+func TestRotatingFileRotatesDueSegmentOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)
+	line := fmt.Sprintf("{\"timestamp\":%q}\n", old)
+	if err := os.WriteFile(filepath.Join(dir, "audit.jsonl"), []byte(line), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewRotatingFile(RotatingFileConfig{Dir: dir, RotateEvery: time.Hour, Compress: true}, nil)
+	if err != nil {
+		t.Fatalf("NewRotatingFile: %v", err)
+	}
+	defer f.Close()
+
+	m := waitForSegments(t, dir, 1)
+	if seg := m.Segments[0]; seg.Events != 1 || filepath.Ext(seg.File) != ".gz" {
+		t.Errorf("segment = %+v, want 1 event, compressed", seg)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "audit.jsonl")); err != nil || info.Size() != 0 {
+		t.Errorf("active segment not restarted: %v, %v", info, err)
+	}
+}
@@ -0,0 +1,160 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/godamri/helix-fnd/http/response"
+	"github.com/godamri/helix-fnd/pkg/contextx"
+)
+
+// DefaultSearchScope is required to query the audit trail unless overridden.
+const DefaultSearchScope = "audit:read"
+
+// Search audit actions, recorded for every query against the trail.
+const (
+	ActionSearch       = "AUDIT_SEARCH"
+	ActionSearchDenied = "AUDIT_SEARCH_DENIED"
+)
+
+// SearchHandler serves GET /audit/events. Mount it behind the auth middleware:
+// it needs the principal and scopes in the context.
+//
+//	GET /audit/events?actor=u-1&action=DELETE_USER&resource_prefix=Order:&trace_id=...
+//	    &from=2024-05-01T00:00:00Z&to=2024-06-01T00:00:00Z&limit=100&cursor=...
+type SearchHandler struct {
+	searcher Searcher
+	auditLog Logger
+	scope    string
+	logger   *slog.Logger
+}
+
+// NewSearchHandler creates the handler. Every query, allowed or denied, is
+// written to auditLog; if that fails, no results are returned.
+// An empty scope defaults to DefaultSearchScope.
+func NewSearchHandler(searcher Searcher, auditLog Logger, scope string, logger *slog.Logger) *SearchHandler {
+	if scope == "" {
+		scope = DefaultSearchScope
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SearchHandler{
+		searcher: searcher,
+		auditLog: auditLog,
+		scope:    scope,
+		logger:   logger,
+	}
+}
+
+func (h *SearchHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/audit/events", h.HandleSearch)
+}
+
+func (h *SearchHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+
+	filters := map[string]string{}
+	for _, k := range []string{"actor", "action", "resource_prefix", "trace_id", "from", "to", "limit", "cursor"} {
+		if v := params.Get(k); v != "" {
+			filters[k] = v
+		}
+	}
+
+	if !contextx.HasAuthScope(ctx, h.scope) {
+		if err := h.record(ctx, ActionSearchDenied, filters); err != nil {
+			h.logger.ErrorContext(ctx, "audit: failed to record denied search", "error", err)
+		}
+		response.ErrorJSON(w, r, http.StatusForbidden, response.ErrForbidden, "missing scope "+h.scope)
+		return
+	}
+
+	q, err := parseQuery(params.Get)
+	if err != nil {
+		h.recordFailure(ctx, filters, "invalid_query", err)
+		response.ErrorJSON(w, r, http.StatusBadRequest, response.ErrInvalidFormat, err.Error())
+		return
+	}
+
+	page, err := h.searcher.Search(ctx, q)
+	if errors.Is(err, ErrInvalidCursor) {
+		h.recordFailure(ctx, filters, "invalid_cursor", err)
+		response.ErrorJSON(w, r, http.StatusBadRequest, response.ErrInvalidFormat, err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.ErrorContext(ctx, "audit: search failed", "error", err)
+		h.recordFailure(ctx, filters, "error", err)
+		response.ErrorJSON(w, r, http.StatusInternalServerError, response.ErrSystem, "audit search failed")
+		return
+	}
+
+	// Reading the trail is itself audited. No record, no results.
+	filters["outcome"] = "success"
+	filters["result_count"] = strconv.Itoa(len(page.Records))
+	if err := h.record(ctx, ActionSearch, filters); err != nil {
+		h.logger.ErrorContext(ctx, "audit: failed to record search", "error", err)
+		response.ErrorJSON(w, r, http.StatusServiceUnavailable, response.ErrServiceUnavail, "audit search unavailable")
+		return
+	}
+
+	response.JSONWithMeta(w, r, http.StatusOK, page.Records, response.Meta{
+		PageSize:   len(page.Records),
+		HasNext:    page.NextCursor != "",
+		NextCursor: page.NextCursor,
+		TraceID:    contextx.GetTraceID(ctx),
+	})
+}
+
+// recordFailure audits a query that failed. The error response goes out either way.
+func (h *SearchHandler) recordFailure(ctx context.Context, filters map[string]string, outcome string, cause error) {
+	filters["outcome"] = outcome
+	filters["error"] = cause.Error()
+	if err := h.record(ctx, ActionSearch, filters); err != nil {
+		h.logger.ErrorContext(ctx, "audit: failed to record search", "error", err)
+	}
+}
+
+func (h *SearchHandler) record(ctx context.Context, action string, filters map[string]string) error {
+	return h.auditLog.Log(ctx, Event{
+		ActorID:  contextx.GetAuthPrincipalID(ctx),
+		Action:   action,
+		Resource: "audit_events",
+		Metadata: filters,
+		TraceID:  contextx.GetTraceID(ctx),
+	})
+}
+
+func parseQuery(get func(string) string) (Query, error) {
+	q := Query{
+		ActorID:        get("actor"),
+		Action:         get("action"),
+		ResourcePrefix: get("resource_prefix"),
+		TraceID:        get("trace_id"),
+		Cursor:         get("cursor"),
+	}
+
+	var err error
+	if v := get("from"); v != "" {
+		if q.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return q, errors.New("from must be an RFC 3339 timestamp")
+		}
+	}
+	if v := get("to"); v != "" {
+		if q.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return q, errors.New("to must be an RFC 3339 timestamp")
+		}
+	}
+	if v := get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit <= 0 {
+			return q, errors.New("limit must be a positive integer")
+		}
+	}
+	return q, nil
+}
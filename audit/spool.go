@@ -0,0 +1,347 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fsync policies for the spool.
+const (
+	FsyncAlways   = "always"   // fsync every spilled event; survives power loss, slowest
+	FsyncInterval = "interval" // fsync at most once per FsyncInterval
+	FsyncNever    = "never"    // leave it to the OS; survives process crashes only
+)
+
+const (
+	defaultSegmentSize   = 64 << 20
+	defaultFsyncInterval = 1 * time.Second
+	cursorSaveEvery      = 100
+
+	segmentPrefix = "segment-"
+	segmentSuffix = ".jsonl"
+	cursorFile    = "cursor.json"
+)
+
+// ErrSpoolFull is returned when the spool reached MaxBytes.
+var ErrSpoolFull = errors.New("audit: spool full, log dropped")
+
+type SpoolConfig struct {
+	Dir           string
+	SegmentSize   int64 // rotate after this many bytes (default 64 MiB)
+	MaxBytes      int64 // stop spilling beyond this; 0 means unlimited
+	Fsync         string
+	FsyncInterval time.Duration
+}
+
+// Spool is an append-only, segmented overflow queue on local disk.
+// AsyncLogger spills into it when its buffer is full and replays it in order
+// once the writer catches up. Replay position is kept in a cursor file, so
+// spilled events survive restarts. Delivery is at-least-once: after a crash,
+// up to 100 events before the last saved cursor may be replayed again.
+type Spool struct {
+	cfg SpoolConfig
+
+	mu       sync.Mutex
+	segments []uint64 // ids on disk, oldest first
+	writer   *os.File
+	written  int64 // size of the write segment
+	total    int64 // unreplayed bytes on disk, approximately
+	lastSync time.Time
+	dirty    bool
+
+	reader  *os.File
+	buf     *bufio.Reader
+	readSeg uint64
+	readOff int64
+	unsaved int
+	notify  chan struct{}
+	closed  bool
+}
+
+type spoolCursor struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// OpenSpool opens or creates a spool in cfg.Dir and resumes from the saved cursor.
+func OpenSpool(cfg SpoolConfig) (*Spool, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("audit: spool dir is required")
+	}
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = defaultSegmentSize
+	}
+	if cfg.FsyncInterval <= 0 {
+		cfg.FsyncInterval = defaultFsyncInterval
+	}
+	switch cfg.Fsync {
+	case "":
+		cfg.Fsync = FsyncInterval
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("audit: unknown spool fsync policy %q", cfg.Fsync)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("audit: failed to create spool dir: %w", err)
+	}
+
+	s := &Spool{cfg: cfg, notify: make(chan struct{}, 1)}
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// recover lists existing segments and positions the reader at the saved cursor.
+func (s *Spool) recover() error {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("audit: failed to read spool dir: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, id)
+		if info, err := e.Info(); err == nil {
+			s.total += info.Size()
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+
+	var cur spoolCursor
+	if data, err := os.ReadFile(filepath.Join(s.cfg.Dir, cursorFile)); err == nil {
+		_ = json.Unmarshal(data, &cur)
+	}
+
+	// Segments before the cursor were fully replayed before a crash.
+	for len(s.segments) > 0 && s.segments[0] < cur.Segment {
+		s.removeSegment(s.segments[0])
+		s.segments = s.segments[1:]
+	}
+	if len(s.segments) > 0 && s.segments[0] == cur.Segment {
+		s.readSeg, s.readOff = cur.Segment, cur.Offset
+		s.total -= cur.Offset
+	} else if len(s.segments) > 0 {
+		s.readSeg, s.readOff = s.segments[0], 0
+	}
+	return nil
+}
+
+// Pending reports whether spilled events are waiting for replay.
+// While it is true, new events must also go to the spool to keep order.
+func (s *Spool) Pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.segments) > 0
+}
+
+// Append spills one event.
+func (s *Spool) Append(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("audit: failed to encode spilled event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("audit: spool closed")
+	}
+	if s.cfg.MaxBytes > 0 && s.total+int64(len(line)) > s.cfg.MaxBytes {
+		return ErrSpoolFull
+	}
+	if s.writer == nil || s.written >= s.cfg.SegmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.writer.Write(line)
+	s.written += int64(n)
+	s.total += int64(n)
+	if err != nil {
+		return fmt.Errorf("audit: failed to spill event: %w", err)
+	}
+	s.dirty = true
+	if err := s.maybeSync(); err != nil {
+		return err
+	}
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *Spool) rotate() error {
+	if s.writer != nil {
+		if err := s.syncLocked(); err != nil {
+			return err
+		}
+		s.writer.Close()
+	}
+
+	id := uint64(time.Now().UnixNano())
+	if n := len(s.segments); n > 0 && id <= s.segments[n-1] {
+		id = s.segments[n-1] + 1
+	}
+	f, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("audit: failed to create spool segment: %w", err)
+	}
+	if len(s.segments) == 0 {
+		s.readSeg, s.readOff = id, 0
+	}
+	s.segments = append(s.segments, id)
+	s.writer, s.written = f, 0
+	return nil
+}
+
+func (s *Spool) maybeSync() error {
+	switch s.cfg.Fsync {
+	case FsyncAlways:
+		return s.syncLocked()
+	case FsyncInterval:
+		if time.Since(s.lastSync) >= s.cfg.FsyncInterval {
+			return s.syncLocked()
+		}
+	}
+	return nil
+}
+
+func (s *Spool) syncLocked() error {
+	if s.writer == nil || !s.dirty {
+		return nil
+	}
+	s.lastSync = time.Now()
+	s.dirty = false
+	if err := s.writer.Sync(); err != nil {
+		return fmt.Errorf("audit: failed to sync spool: %w", err)
+	}
+	return nil
+}
+
+// Next returns the oldest spilled event. ok is false when the spool is drained.
+// A corrupt record (e.g. torn by a crash mid-write) is skipped and reported as an error.
+func (s *Spool) Next() (event Event, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.segments) > 0 {
+		if s.reader == nil {
+			f, err := os.Open(s.segmentPath(s.readSeg))
+			if err != nil {
+				return Event{}, false, fmt.Errorf("audit: failed to open spool segment: %w", err)
+			}
+			if _, err := f.Seek(s.readOff, io.SeekStart); err != nil {
+				f.Close()
+				return Event{}, false, fmt.Errorf("audit: failed to seek spool segment: %w", err)
+			}
+			s.reader, s.buf = f, bufio.NewReader(f)
+		}
+
+		// Appends happen under mu as whole lines, so a short read is only a torn
+		// tail from a previous crash.
+		line, err := s.buf.ReadBytes('\n')
+		if len(line) > 0 && err == nil {
+			s.readOff += int64(len(line))
+			s.total -= int64(len(line))
+			s.advanced()
+			if jerr := json.Unmarshal(line, &event); jerr != nil {
+				return Event{}, true, fmt.Errorf("audit: skipped corrupt spool record: %w", jerr)
+			}
+			return event, true, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Event{}, false, fmt.Errorf("audit: failed to read spool segment: %w", err)
+		}
+
+		// Segment exhausted.
+		s.reader.Close()
+		s.reader, s.buf = nil, nil
+		done := s.segments[0]
+		if len(s.segments) == 1 && s.writer != nil {
+			// Drained the live segment; the next spill starts a fresh one.
+			s.writer.Close()
+			s.writer = nil
+		}
+		s.removeSegment(done)
+		s.segments = s.segments[1:]
+		s.total -= int64(len(line))
+		if len(s.segments) > 0 {
+			s.readSeg, s.readOff = s.segments[0], 0
+		} else {
+			// Fully drained: reset so a new segment can never sort before the cursor.
+			s.readSeg, s.readOff, s.total = 0, 0, 0
+		}
+		s.saveCursor()
+	}
+	return Event{}, false, nil
+}
+
+func (s *Spool) advanced() {
+	s.unsaved++
+	if s.unsaved >= cursorSaveEvery {
+		s.saveCursor()
+	}
+}
+
+// saveCursor persists the replay position atomically. Failure only means
+// more duplicates after a crash, so it is not reported.
+func (s *Spool) saveCursor() {
+	s.unsaved = 0
+	data, _ := json.Marshal(spoolCursor{Segment: s.readSeg, Offset: s.readOff})
+	tmp := filepath.Join(s.cfg.Dir, cursorFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, filepath.Join(s.cfg.Dir, cursorFile))
+}
+
+func (s *Spool) removeSegment(id uint64) {
+	_ = os.Remove(s.segmentPath(id))
+}
+
+func (s *Spool) segmentPath(id uint64) string {
+	return filepath.Join(s.cfg.Dir, fmt.Sprintf("%s%020d%s", segmentPrefix, id, segmentSuffix))
+}
+
+// Close syncs spilled data and saves the replay position. Unreplayed events stay
+// on disk and are replayed by the next process that opens the spool.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var errs []error
+	if s.writer != nil {
+		errs = append(errs, s.syncLocked(), s.writer.Close())
+	}
+	if s.reader != nil {
+		s.reader.Close()
+	}
+	s.saveCursor()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKey = []byte("test-key")
+
+// sealedLines returns n sealed events of one chain, one JSON line each.
+func sealedLines(t *testing.T, n int) []string {
+	t.Helper()
+	chain := NewChain(testKey, "test-chain")
+	lines := make([]string, n)
+	for i := range lines {
+		e := Event{Action: "UPDATE", Resource: "order", Timestamp: time.Unix(int64(i), 0).UTC()}
+		if err := chain.Seal(&e); err != nil {
+			t.Fatalf("Seal: %v", err)
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		lines[i] = string(data)
+	}
+	return lines
+}
+
+func TestVerifyLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		edit   func([]string) []string
+		kinds  []string
+		intact bool
+	}{
+		{
+			name:   "intact",
+			edit:   func(l []string) []string { return l },
+			intact: true,
+		},
+		{
+			name:  "gap",
+			edit:  func(l []string) []string { return append(l[:2:2], l[3:]...) },
+			kinds: []string{FindingGap},
+		},
+		{
+			name:  "missing head",
+			edit:  func(l []string) []string { return l[1:] },
+			kinds: []string{FindingGap},
+		},
+		{
+			name:  "duplicate",
+			edit:  func(l []string) []string { return append(l[:3:3], l[2:]...) },
+			kinds: []string{FindingDuplicate},
+		},
+		{
+			name: "reorder",
+			edit: func(l []string) []string {
+				l[1], l[2] = l[2], l[1]
+				return l
+			},
+			kinds:  []string{FindingReorder},
+			intact: true,
+		},
+		{
+			name: "modified",
+			edit: func(l []string) []string {
+				l[2] = strings.Replace(l[2], `"resource":"order"`, `"resource":"invoice"`, 1)
+				return l
+			},
+			kinds: []string{FindingModified},
+		},
+		{
+			name: "malformed and unsealed",
+			edit: func(l []string) []string {
+				return append(l, "{not json", `{"action":"UPDATE"}`)
+			},
+			kinds: []string{FindingMalformed, FindingUnsealed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := tt.edit(sealedLines(t, 5))
+			report, err := VerifyLog(strings.NewReader(strings.Join(lines, "\n")), testKey)
+			if err != nil {
+				t.Fatalf("VerifyLog: %v", err)
+			}
+
+			var kinds []string
+			for _, f := range report.Findings {
+				kinds = append(kinds, f.Kind)
+			}
+			if strings.Join(kinds, ",") != strings.Join(tt.kinds, ",") {
+				t.Errorf("findings = %v, want %v", report.Findings, tt.kinds)
+			}
+			if report.Intact() != tt.intact {
+				t.Errorf("Intact() = %v, want %v", report.Intact(), tt.intact)
+			}
+		})
+	}
+}
+
+func TestVerifyLogWrongKey(t *testing.T) {
+	lines := sealedLines(t, 3)
+	report, err := VerifyLog(bytes.NewBufferString(strings.Join(lines, "\n")), []byte("other-key"))
+	if err != nil {
+		t.Fatalf("VerifyLog: %v", err)
+	}
+	if len(report.Findings) != 3 {
+		t.Fatalf("findings = %v, want 3 modified", report.Findings)
+	}
+	for _, f := range report.Findings {
+		if f.Kind != FindingModified {
+			t.Errorf("finding = %v, want %s", f, FindingModified)
+		}
+	}
+}
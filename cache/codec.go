@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Codec encodes cached values.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSON is the default codec.
+var JSON Codec = jsonCodec{}
+
+// Gob encodes with encoding/gob. Register interface types with gob.Register.
+var Gob Codec = gobCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+const (
+	rawPayload  byte = 0
+	gzipPayload byte = 1
+)
+
+// Compressed gzips values of at least minSize bytes encoded by inner.
+// Smaller values are stored as-is; a one byte header tells them apart.
+func Compressed(inner Codec, minSize int) Codec {
+	return compressedCodec{inner: inner, minSize: minSize}
+}
+
+type compressedCodec struct {
+	inner   Codec
+	minSize int
+}
+
+func (c compressedCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.inner.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < c.minSize {
+		return append([]byte{rawPayload}, data...), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(gzipPayload)
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c compressedCodec) Unmarshal(data []byte, v any) error {
+	if len(data) == 0 {
+		return fmt.Errorf("cache: empty compressed payload")
+	}
+	switch data[0] {
+	case rawPayload:
+		return c.inner.Unmarshal(data[1:], v)
+	case gzipPayload:
+		zr, err := gzip.NewReader(bytes.NewReader(data[1:]))
+		if err != nil {
+			return err
+		}
+		raw, err := io.ReadAll(zr)
+		if err != nil {
+			return err
+		}
+		return c.inner.Unmarshal(raw, v)
+	default:
+		return fmt.Errorf("cache: unknown compression header %d", data[0])
+	}
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLockHeld is returned by TryAcquire when another owner holds the lock.
+	ErrLockHeld = errors.New("cache: lock held by another owner")
+	// ErrLockLost is returned by Release when the lease expired or was taken over.
+	ErrLockLost = errors.New("cache: lock lost")
+)
+
+// Both keys share the {name} hash tag, so the scripts work on Redis Cluster.
+// The fence counter never expires: tokens keep increasing across holders.
+var (
+	luaLockAcquire = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+    return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+	luaLockRenew = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+    return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+	luaLockRelease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+    return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+)
+
+// Locker hands out leases on named locks stored in Redis.
+type Locker struct {
+	rdb    redis.UniversalClient
+	logger *slog.Logger
+}
+
+// NewLocker returns a Locker on rdb. Lost leases and renewal failures go to logger.
+func NewLocker(rdb redis.UniversalClient, logger *slog.Logger) *Locker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Locker{rdb: rdb, logger: logger.With("component", "cache_locker")}
+}
+
+// Lock is a held lease. It is renewed every ttl/3 until Release is called or
+// the context passed to Acquire ends, whichever comes first.
+type Lock struct {
+	locker *Locker
+	name   string
+	owner  string
+	fence  int64
+	ttl    time.Duration
+
+	lost     chan struct{}
+	lostOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func lockKeys(name string) []string {
+	return []string{"lock:{" + name + "}", "lock:{" + name + "}:fence"}
+}
+
+// TryAcquire takes the lock once, returning ErrLockHeld if it is taken.
+func (l *Locker) TryAcquire(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if ttl < time.Millisecond {
+		return nil, errors.New("cache: lock ttl must be at least 1ms")
+	}
+	owner := uuid.NewString()
+
+	fence, err := luaLockAcquire.Run(ctx, l.rdb, lockKeys(name), owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("cache: failed to acquire lock %s: %w", name, err)
+	}
+	if fence == 0 {
+		return nil, ErrLockHeld
+	}
+
+	lk := &Lock{
+		locker: l,
+		name:   name,
+		owner:  owner,
+		fence:  fence,
+		ttl:    ttl,
+		lost:   make(chan struct{}),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go lk.renew(ctx)
+	return lk, nil
+}
+
+// Acquire waits for the lock until ctx ends, polling with capped exponential backoff.
+func (l *Locker) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	wait := 50 * time.Millisecond
+	for {
+		lk, err := l.TryAcquire(ctx, name, ttl)
+		if !errors.Is(err, ErrLockHeld) {
+			return lk, err
+		}
+
+		timer := time.NewTimer(wait/2 + rand.N(wait/2))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		wait = min(wait*2, time.Second)
+	}
+}
+
+// Fence returns the fencing token: it increases with every acquisition of this
+// lock name. Store it with the writes the lock protects and reject writes that
+// carry a lower token than the last one seen (e.g. UPDATE ... WHERE fence < $1).
+func (lk *Lock) Fence() int64 {
+	return lk.fence
+}
+
+// Lost is closed when the lease can no longer be renewed, or was released
+// because the Acquire context ended. The holder must stop work that relies on exclusion.
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Release stops renewal and deletes the lock if this lease still owns it.
+func (lk *Lock) Release(ctx context.Context) error {
+	lk.stopOnce.Do(func() { close(lk.stop) })
+	<-lk.done
+
+	n, err := luaLockRelease.Run(ctx, lk.locker.rdb, lockKeys(lk.name)[:1], lk.owner).Int64()
+	if err != nil {
+		return fmt.Errorf("cache: failed to release lock %s: %w", lk.name, err)
+	}
+	if n == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (lk *Lock) renew(ctx context.Context) {
+	defer close(lk.done)
+
+	ticker := time.NewTicker(lk.ttl / 3)
+	defer ticker.Stop()
+	expires := time.Now().Add(lk.ttl)
+
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ctx.Done():
+			// The holder is gone: let the next owner in now rather than after the TTL.
+			rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+			_ = luaLockRelease.Run(rctx, lk.locker.rdb, lockKeys(lk.name)[:1], lk.owner).Err()
+			cancel()
+			lk.markLost()
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		n, err := luaLockRenew.Run(ctx, lk.locker.rdb, lockKeys(lk.name)[:1], lk.owner, lk.ttl.Milliseconds()).Int64()
+		switch {
+		case err == nil && n == 1:
+			expires = start.Add(lk.ttl)
+		case err == nil:
+			lk.locker.logger.Error("cache: lock taken over", "lock", lk.name, "fence", lk.fence)
+			lk.markLost()
+			return
+		case time.Now().After(expires):
+			lk.locker.logger.Error("cache: lock expired before renewal", "lock", lk.name, "fence", lk.fence, "error", err)
+			lk.markLost()
+			return
+		default:
+			// Transient failure: the lease is still valid, retry on the next tick.
+			lk.locker.logger.Warn("cache: lock renewal failed", "lock", lk.name, "error", err)
+		}
+	}
+}
+
+func (lk *Lock) markLost() {
+	lk.lostOnce.Do(func() { close(lk.lost) })
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestLocker(t *testing.T) (*Locker, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return NewLocker(rdb, nil), mr
+}
+
+func TestLockExclusionAndFencing(t *testing.T) {
+	l, _ := newTestLocker(t)
+	ctx := context.Background()
+
+	var last int64
+	for i := 0; i < 3; i++ {
+		lk, err := l.TryAcquire(ctx, "job", time.Second)
+		if err != nil {
+			t.Fatalf("TryAcquire %d: %v", i, err)
+		}
+		if lk.Fence() <= last {
+			t.Fatalf("fence %d after %d, want increasing", lk.Fence(), last)
+		}
+		last = lk.Fence()
+
+		if _, err := l.TryAcquire(ctx, "job", time.Second); !errors.Is(err, ErrLockHeld) {
+			t.Fatalf("second TryAcquire error = %v, want ErrLockHeld", err)
+		}
+		if err := lk.Release(ctx); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+	}
+
+	other, err := l.TryAcquire(ctx, "other-job", time.Second)
+	if err != nil {
+		t.Fatalf("TryAcquire other: %v", err)
+	}
+	defer other.Release(ctx)
+	if other.Fence() != 1 {
+		t.Errorf("other lock fence = %d, want 1", other.Fence())
+	}
+}
+
+func TestLockAcquireWaits(t *testing.T) {
+	l, _ := newTestLocker(t)
+	ctx := context.Background()
+
+	lk, err := l.TryAcquire(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() { _ = lk.Release(ctx) })
+
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	next, err := l.Acquire(waitCtx, "job", time.Second)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer next.Release(ctx)
+	if next.Fence() <= lk.Fence() {
+		t.Errorf("fence %d after %d, want increasing", next.Fence(), lk.Fence())
+	}
+
+	shortCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(shortCtx, "job", time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire on held lock error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestLockRenewal(t *testing.T) {
+	l, mr := newTestLocker(t)
+	ctx := context.Background()
+
+	lk, err := l.TryAcquire(ctx, "job", 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	defer lk.Release(ctx)
+
+	// Redis time runs three TTLs ahead; renewal must keep the lease alive.
+	for i := 0; i < 9; i++ {
+		time.Sleep(100 * time.Millisecond)
+		mr.FastForward(100 * time.Millisecond)
+	}
+	select {
+	case <-lk.Lost():
+		t.Fatal("lease lost despite renewal")
+	default:
+	}
+	if !mr.Exists("lock:{job}") {
+		t.Fatal("lock key expired despite renewal")
+	}
+}
+
+func TestLockTakeover(t *testing.T) {
+	l, mr := newTestLocker(t)
+	ctx := context.Background()
+
+	lk, err := l.TryAcquire(ctx, "job", 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	// Another owner got the lock, e.g. after this process stalled past the TTL.
+	if err := mr.Set("lock:{job}", "someone-else"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-lk.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Lost not closed after takeover")
+	}
+	if err := lk.Release(ctx); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Release error = %v, want ErrLockLost", err)
+	}
+	if got, _ := mr.Get("lock:{job}"); got != "someone-else" {
+		t.Errorf("Release deleted the new owner's lock")
+	}
+}
+
+func TestLockReleasedWhenContextEnds(t *testing.T) {
+	l, mr := newTestLocker(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lk, err := l.TryAcquire(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	cancel()
+
+	select {
+	case <-lk.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Lost not closed after context cancel")
+	}
+	if mr.Exists("lock:{job}") {
+		t.Error("lock not released after context cancel")
+	}
+}
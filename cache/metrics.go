@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Lookup results used in cache_requests_total.
+const (
+	ResultHit         = "hit"
+	ResultLocalHit    = "local_hit"
+	ResultNegativeHit = "negative_hit"
+	ResultMiss        = "miss"
+	ResultError       = "error"
+)
+
+// Metrics instruments caches and Redis clients. Create one per registry and
+// share it; each Store reports under its own "cache" label (its name) and each
+// client instrumented with InstrumentRedis under its "client" label.
+// A nil *Metrics records nothing.
+type Metrics struct {
+	reg          prometheus.Registerer
+	requests     *prometheus.CounterVec
+	loads        *prometheus.CounterVec
+	loadDuration *prometheus.HistogramVec
+	refreshes    *prometheus.CounterVec
+
+	commandDuration *prometheus.HistogramVec
+	commandErrors   *prometheus.CounterVec
+}
+
+// NewMetrics registers the cache metrics with reg (prometheus.DefaultRegisterer if nil).
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	f := promauto.With(reg)
+
+	return &Metrics{
+		reg: reg,
+		requests: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Cache lookups, by cache and result (local_hit, hit, negative_hit, miss, error).",
+		}, []string{"cache", "result"}),
+		loads: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_loads_total",
+			Help: "Loader calls after a miss or early refresh, by cache and outcome (success, not_found, error).",
+		}, []string{"cache", "outcome"}),
+		loadDuration: f.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_load_duration_seconds",
+			Help:    "Loader latency, by cache.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"cache"}),
+		refreshes: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_early_refreshes_total",
+			Help: "Background refreshes of entries close to expiry, by cache.",
+		}, []string{"cache"}),
+		commandDuration: f.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "redis_command_duration_seconds",
+			Help:    "Redis command latency, by client and command. Pipelines are observed once as \"pipeline\".",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"client", "command"}),
+		commandErrors: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "redis_command_errors_total",
+			Help: "Failed Redis commands (redis.Nil excluded), by client and command, including commands in pipelines.",
+		}, []string{"client", "command"}),
+	}
+}
+
+func (m *Metrics) request(cache, result string) {
+	if m != nil {
+		m.requests.WithLabelValues(cache, result).Inc()
+	}
+}
+
+func (m *Metrics) load(cache, outcome string, start time.Time) {
+	if m != nil {
+		m.loads.WithLabelValues(cache, outcome).Inc()
+		m.loadDuration.WithLabelValues(cache).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) refresh(cache string) {
+	if m != nil {
+		m.refreshes.WithLabelValues(cache).Inc()
+	}
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// NearConfig sizes the in-process tier of a NearCache.
+type NearConfig struct {
+	// Size is the maximum number of local entries (default 10000).
+	Size int
+	// TTL bounds how long a local entry is served without asking Redis (default 30s).
+	// It is also the worst-case staleness for values rewritten by an early refresh.
+	TTL time.Duration
+	// PingInterval is how often an idle subscription is checked (default 5s).
+	PingInterval time.Duration
+}
+
+// NearCache puts a bounded in-process LRU in front of a Store. Set and Delete
+// publish the key on a Redis channel so every instance drops its local copy.
+//
+// The local tier is only used while the invalidation subscription is live:
+// it is flushed and bypassed when the subscription fails, and flushed again
+// once it is re-established, since messages sent in between are lost.
+type NearCache[T any] struct {
+	store   *Store[T]
+	cfg     NearConfig
+	local   *lru[T]
+	logger  *slog.Logger
+	channel string
+	origin  string
+
+	// live is true while subscribed; gen changes on every invalidation so a
+	// load racing with one does not put its (possibly stale) value back.
+	live atomic.Bool
+	gen  atomic.Uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewNearCache layers a local tier over store. Call Start before use;
+// until then every call goes to Redis.
+func NewNearCache[T any](store *Store[T], cfg NearConfig, logger *slog.Logger) *NearCache[T] {
+	if cfg.Size <= 0 {
+		cfg.Size = 10000
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 30 * time.Second
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 5 * time.Second
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &NearCache[T]{
+		store:   store,
+		cfg:     cfg,
+		local:   newLRU[T](cfg.Size, cfg.TTL),
+		logger:  logger.With("component", "near_cache", "cache", store.name),
+		channel: "cache:invalidate:" + store.name,
+		origin:  uuid.NewString(),
+	}
+}
+
+// Start subscribes to the invalidation channel. It fails if Redis is unreachable.
+func (n *NearCache[T]) Start(ctx context.Context) error {
+	if n.done != nil {
+		return errors.New("cache: near cache already started")
+	}
+
+	ps := n.store.rdb.Subscribe(ctx, n.channel)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return err
+	}
+	n.live.Store(true)
+
+	ctx, n.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	n.done = make(chan struct{})
+	go n.run(ctx, ps)
+	return nil
+}
+
+// Stop closes the subscription and disables the local tier.
+func (n *NearCache[T]) Stop(ctx context.Context) error {
+	if n.cancel == nil {
+		return nil
+	}
+	n.cancel()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *NearCache[T]) run(ctx context.Context, ps *redis.PubSub) {
+	defer close(n.done)
+	defer ps.Close()
+	defer n.disable()
+
+	for {
+		msg, err := ps.ReceiveTimeout(ctx, n.cfg.PingInterval)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if err = ps.Ping(ctx); err == nil {
+					continue
+				}
+			}
+			if n.live.Load() {
+				n.logger.Warn("cache: invalidation subscription lost, local tier disabled", "error", err)
+			}
+			n.disable()
+
+			// The next Receive reconnects and resubscribes.
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				n.disable()
+				n.live.Store(true)
+				n.logger.Info("cache: invalidation subscription restored")
+			}
+		case *redis.Message:
+			origin, key, ok := strings.Cut(m.Payload, "\x00")
+			if ok && origin != n.origin {
+				n.invalidate(key)
+			}
+		}
+	}
+}
+
+func (n *NearCache[T]) invalidate(key string) {
+	n.gen.Add(1)
+	n.local.remove(key)
+}
+
+// disable stops serving from the local tier and drops everything in it.
+func (n *NearCache[T]) disable() {
+	n.live.Store(false)
+	n.gen.Add(1)
+	n.local.flush()
+}
+
+// GetOrLoad serves key from the local tier, then from the Store (see Store.GetOrLoad).
+func (n *NearCache[T]) GetOrLoad(ctx context.Context, key string, load Loader[T]) (T, error) {
+	if n.live.Load() {
+		if v, ok := n.local.get(key); ok {
+			n.store.opts.metrics.request(n.store.name, ResultLocalHit)
+			return v, nil
+		}
+	}
+
+	gen := n.gen.Load()
+	v, err := n.store.GetOrLoad(ctx, key, load)
+	if err == nil && n.live.Load() && n.gen.Load() == gen {
+		n.local.put(key, v)
+	}
+	return v, err
+}
+
+// Set writes v through to Redis and invalidates key on every other instance.
+func (n *NearCache[T]) Set(ctx context.Context, key string, v T) error {
+	n.invalidate(key)
+	if err := n.store.Set(ctx, key, v); err != nil {
+		return err
+	}
+	if err := n.publish(ctx, key); err != nil {
+		return err
+	}
+	if n.live.Load() {
+		n.local.put(key, v)
+	}
+	return nil
+}
+
+// Delete removes keys from Redis and from every instance's local tier.
+func (n *NearCache[T]) Delete(ctx context.Context, keys ...string) error {
+	for _, k := range keys {
+		n.invalidate(k)
+	}
+	if err := n.store.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := n.publish(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *NearCache[T]) publish(ctx context.Context, key string) error {
+	return n.store.rdb.Publish(ctx, n.channel, n.origin+"\x00"+key).Err()
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestNearCaches starts n near caches named "users" over one miniredis,
+// each with its own client, as separate instances would have.
+func newTestNearCaches(t *testing.T, n int) []*NearCache[string] {
+	t.Helper()
+	mr := miniredis.RunT(t)
+
+	caches := make([]*NearCache[string], n)
+	for i := range caches {
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = rdb.Close() })
+
+		c := NewNearCache(NewStore[string](rdb, "users"), NearConfig{TTL: time.Minute, PingInterval: 50 * time.Millisecond}, nil)
+		if err := c.Start(context.Background()); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Stop(context.Background()) })
+		caches[i] = c
+	}
+	return caches
+}
+
+func noLoad(context.Context) (string, error) {
+	return "", errors.New("unexpected load")
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNearCacheInvalidatesOtherInstances(t *testing.T) {
+	caches := newTestNearCaches(t, 2)
+	a, b := caches[0], caches[1]
+	ctx := context.Background()
+
+	gen := b.gen.Load()
+	if err := a.Set(ctx, "1", "v1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	waitFor(t, "b to see the invalidation", func() bool { return b.gen.Load() != gen })
+	if v, err := b.GetOrLoad(ctx, "1", noLoad); err != nil || v != "v1" {
+		t.Fatalf("b.GetOrLoad = %q, %v", v, err)
+	}
+
+	// A write that skips the channel is not seen: b serves its local copy.
+	if err := a.store.Set(ctx, "1", "unpublished"); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	if v, _ := b.GetOrLoad(ctx, "1", noLoad); v != "v1" {
+		t.Fatalf("b.GetOrLoad = %q, want the local v1", v)
+	}
+
+	if err := a.Set(ctx, "1", "v2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	waitFor(t, "b to drop its local copy", func() bool {
+		_, ok := b.local.get("1")
+		return !ok
+	})
+	if v, err := b.GetOrLoad(ctx, "1", noLoad); err != nil || v != "v2" {
+		t.Errorf("b.GetOrLoad = %q, %v, want v2", v, err)
+	}
+	// a ignores its own message and keeps serving what it wrote.
+	if v, ok := a.local.get("1"); !ok || v != "v2" {
+		t.Errorf("a local = %q, %v, want v2", v, ok)
+	}
+
+	if err := b.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	waitFor(t, "a to drop its local copy", func() bool {
+		_, ok := a.local.get("1")
+		return !ok
+	})
+}
+
+// A load that overlaps an invalidation must not put its result in the local tier:
+// it may have read the value the invalidation replaced.
+func TestNearCacheLoadRacingInvalidation(t *testing.T) {
+	caches := newTestNearCaches(t, 2)
+	a, b := caches[0], caches[1]
+	ctx := context.Background()
+
+	v, err := a.GetOrLoad(ctx, "1", func(ctx context.Context) (string, error) {
+		gen := a.gen.Load()
+		if err := b.Set(ctx, "1", "fresh"); err != nil {
+			return "", err
+		}
+		waitFor(t, "a to see the invalidation", func() bool { return a.gen.Load() != gen })
+		return "stale", nil
+	})
+	if err != nil || v != "stale" {
+		t.Fatalf("GetOrLoad = %q, %v", v, err)
+	}
+	if v, ok := a.local.get("1"); ok {
+		t.Errorf("local tier kept %q from a load that raced an invalidation", v)
+	}
+
+	// Without a race the result is kept. gen is shared by all keys, so let
+	// b's invalidation for "2" arrive first.
+	gen := a.gen.Load()
+	if err := b.Set(ctx, "2", "v"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	waitFor(t, "a to see the invalidation", func() bool { return a.gen.Load() != gen })
+	if _, err := a.GetOrLoad(ctx, "2", noLoad); err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if _, ok := a.local.get("2"); !ok {
+		t.Error("local tier did not keep an uncontended load")
+	}
+}
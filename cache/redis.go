@@ -2,8 +2,12 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -13,19 +17,123 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// Connection modes.
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
 type Config struct {
-	Addr     string `envconfig:"REDIS_ADDR" required:"true"`
-	Password string `envconfig:"REDIS_PASSWORD" default:""`
-	DB       int    `envconfig:"REDIS_DB" default:"0"`
+	Mode string `envconfig:"REDIS_MODE" default:"standalone" validate:"oneof=standalone sentinel cluster" desc:"Redis topology: standalone, sentinel or cluster"`
+
+	Addr string `envconfig:"REDIS_ADDR" validate:"required_if=Mode standalone" desc:"Redis host:port (standalone)"`
+
+	ClusterAddrs []string `envconfig:"REDIS_CLUSTER_ADDRS" validate:"required_if=Mode cluster" desc:"Cluster seed nodes, host:port"`
+
+	SentinelAddrs []string `envconfig:"REDIS_SENTINEL_ADDRS" validate:"required_if=Mode sentinel" desc:"Sentinel nodes, host:port"`
+
+	SentinelMaster string `envconfig:"REDIS_SENTINEL_MASTER" validate:"required_if=Mode sentinel" desc:"Sentinel master name"`
+
+	SentinelUsername string `envconfig:"REDIS_SENTINEL_USERNAME" desc:"ACL username for the sentinels"`
+
+	SentinelPassword string `envconfig:"REDIS_SENTINEL_PASSWORD" secret:"true" desc:"Password for the sentinels"`
+
+	Username string `envconfig:"REDIS_USERNAME" desc:"Redis ACL username"`
+
+	Password string `envconfig:"REDIS_PASSWORD" default:"" secret:"true" desc:"Redis password"`
+
+	DB int `envconfig:"REDIS_DB" default:"0" desc:"Redis database index (ignored in cluster mode)"`
+
+	TLS bool `envconfig:"REDIS_TLS" default:"false" desc:"Connect over TLS"`
+
+	TLSCACert string `envconfig:"REDIS_TLS_CA_CERT" desc:"Path to the CA certificate (default: system roots)"`
+
+	TLSCert string `envconfig:"REDIS_TLS_CERT" desc:"Path to the client certificate for mutual TLS"`
+
+	TLSKey string `envconfig:"REDIS_TLS_KEY" desc:"Path to the client key for mutual TLS"`
+
+	TLSServerName string `envconfig:"REDIS_TLS_SERVER_NAME" desc:"Expected server name (default: host of the address)"`
+
+	PoolSize int `envconfig:"REDIS_POOL_SIZE" default:"0" desc:"Connections per node (0 = 10 per CPU)"`
+
+	MinIdleConns int `envconfig:"REDIS_MIN_IDLE_CONNS" default:"0" desc:"Idle connections kept open per node"`
+
+	PoolTimeout time.Duration `envconfig:"REDIS_POOL_TIMEOUT" default:"4s" desc:"Wait for a free connection before failing"`
+
+	ConnMaxIdleTime time.Duration `envconfig:"REDIS_CONN_MAX_IDLE_TIME" default:"30m" desc:"Close connections idle for longer"`
+
+	DialTimeout time.Duration `envconfig:"REDIS_DIAL_TIMEOUT" default:"5s" desc:"Connect timeout"`
+
+	ReadTimeout time.Duration `envconfig:"REDIS_READ_TIMEOUT" default:"3s" desc:"Socket read timeout"`
+
+	WriteTimeout time.Duration `envconfig:"REDIS_WRITE_TIMEOUT" default:"3s" desc:"Socket write timeout"`
+
+	MaxRetries int `envconfig:"REDIS_MAX_RETRIES" default:"3" desc:"Retries per command (-1 disables)"`
 }
 
-// NewRedis initializes a Redis client and performs a fail-fast ping.
-func NewRedis(ctx context.Context, cfg Config) (*redis.Client, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.Addr,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+// NewRedis initializes a Redis client for the configured mode and performs a fail-fast ping.
+func NewRedis(ctx context.Context, cfg Config) (redis.UniversalClient, error) {
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var rdb redis.UniversalClient
+	switch cfg.Mode {
+	case ModeStandalone, "":
+		rdb = redis.NewClient(&redis.Options{
+			Addr:            cfg.Addr,
+			Username:        cfg.Username,
+			Password:        cfg.Password,
+			DB:              cfg.DB,
+			TLSConfig:       tlsConfig,
+			PoolSize:        cfg.PoolSize,
+			MinIdleConns:    cfg.MinIdleConns,
+			PoolTimeout:     cfg.PoolTimeout,
+			ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+			DialTimeout:     cfg.DialTimeout,
+			ReadTimeout:     cfg.ReadTimeout,
+			WriteTimeout:    cfg.WriteTimeout,
+			MaxRetries:      cfg.MaxRetries,
+		})
+	case ModeSentinel:
+		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.SentinelMaster,
+			SentinelAddrs:    cfg.SentinelAddrs,
+			SentinelUsername: cfg.SentinelUsername,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			TLSConfig:        tlsConfig,
+			PoolSize:         cfg.PoolSize,
+			MinIdleConns:     cfg.MinIdleConns,
+			PoolTimeout:      cfg.PoolTimeout,
+			ConnMaxIdleTime:  cfg.ConnMaxIdleTime,
+			DialTimeout:      cfg.DialTimeout,
+			ReadTimeout:      cfg.ReadTimeout,
+			WriteTimeout:     cfg.WriteTimeout,
+			MaxRetries:       cfg.MaxRetries,
+		})
+	case ModeCluster:
+		rdb = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:           cfg.ClusterAddrs,
+			Username:        cfg.Username,
+			Password:        cfg.Password,
+			TLSConfig:       tlsConfig,
+			PoolSize:        cfg.PoolSize,
+			MinIdleConns:    cfg.MinIdleConns,
+			PoolTimeout:     cfg.PoolTimeout,
+			ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+			DialTimeout:     cfg.DialTimeout,
+			ReadTimeout:     cfg.ReadTimeout,
+			WriteTimeout:    cfg.WriteTimeout,
+			MaxRetries:      cfg.MaxRetries,
+		})
+	default:
+		return nil, fmt.Errorf("cache: unknown redis mode %q", cfg.Mode)
+	}
 
 	rdb.AddHook(newRedisTracingHook())
 
@@ -35,12 +143,63 @@ func NewRedis(ctx context.Context, cfg Config) (*redis.Client, error) {
 
 	if err := rdb.Ping(pingCtx).Err(); err != nil {
 		_ = rdb.Close()
-		return nil, fmt.Errorf("cache: failed to connect to redis at %s: %w", cfg.Addr, err)
+		return nil, fmt.Errorf("cache: failed to connect to redis (%s) at %s: %w", cfg.mode(), cfg.addrs(), err)
 	}
 
 	return rdb, nil
 }
 
+func (c Config) mode() string {
+	if c.Mode == "" {
+		return ModeStandalone
+	}
+	return c.Mode
+}
+
+func (c Config) addrs() string {
+	switch c.Mode {
+	case ModeSentinel:
+		return c.SentinelMaster + "@" + strings.Join(c.SentinelAddrs, ",")
+	case ModeCluster:
+		return strings.Join(c.ClusterAddrs, ",")
+	default:
+		return c.Addr
+	}
+}
+
+// tlsConfig returns nil when TLS is disabled.
+func (c Config) tlsConfig() (*tls.Config, error) {
+	if !c.TLS {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName: c.TLSServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if c.TLSCACert != "" {
+		caCert, err := os.ReadFile(c.TLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("cache: could not read redis CA cert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("cache: failed to append redis CA cert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.TLSCert != "" || c.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("cache: could not load redis client cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
 type redisTracingHook struct {
 	tracer trace.Tracer
 }
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// InstrumentRedis adds a hook recording command latency and errors for rdb, and
+// registers a collector exporting its connection pool statistics. The client
+// label tells several clients apart on one registry.
+func (m *Metrics) InstrumentRedis(rdb redis.UniversalClient, client string) error {
+	if m == nil {
+		return nil
+	}
+	if err := m.reg.Register(newPoolCollector(rdb, client)); err != nil {
+		return err
+	}
+	rdb.AddHook(&redisMetricsHook{metrics: m, client: client})
+	return nil
+}
+
+type redisMetricsHook struct {
+	metrics *Metrics
+	client  string
+}
+
+func (h *redisMetricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *redisMetricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+
+		h.metrics.commandDuration.WithLabelValues(h.client, cmd.Name()).Observe(time.Since(start).Seconds())
+		if err != nil && !errors.Is(err, redis.Nil) {
+			h.metrics.commandErrors.WithLabelValues(h.client, cmd.Name()).Inc()
+		}
+		return err
+	}
+}
+
+func (h *redisMetricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+
+		h.metrics.commandDuration.WithLabelValues(h.client, "pipeline").Observe(time.Since(start).Seconds())
+		for _, cmd := range cmds {
+			if cerr := cmd.Err(); cerr != nil && !errors.Is(cerr, redis.Nil) {
+				h.metrics.commandErrors.WithLabelValues(h.client, cmd.Name()).Inc()
+			}
+		}
+		return err
+	}
+}
+
+// poolCollector reads PoolStats at scrape time.
+type poolCollector struct {
+	rdb redis.UniversalClient
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newPoolCollector(rdb redis.UniversalClient, client string) *poolCollector {
+	labels := prometheus.Labels{"client": client}
+	return &poolCollector{
+		rdb:        rdb,
+		hits:       prometheus.NewDesc("redis_pool_hits_total", "Times a free connection was found in the pool.", nil, labels),
+		misses:     prometheus.NewDesc("redis_pool_misses_total", "Times no free connection was found and a new one was dialed.", nil, labels),
+		timeouts:   prometheus.NewDesc("redis_pool_timeouts_total", "Times waiting for a connection timed out. Any increase means the pool is saturated.", nil, labels),
+		totalConns: prometheus.NewDesc("redis_pool_total_connections", "Open connections in the pool.", nil, labels),
+		idleConns:  prometheus.NewDesc("redis_pool_idle_connections", "Idle connections in the pool.", nil, labels),
+		staleConns: prometheus.NewDesc("redis_pool_stale_connections_total", "Stale connections removed from the pool.", nil, labels),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.rdb.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(s.StaleConns))
+}
@@ -0,0 +1,282 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNotFound is returned by a Loader when the value does not exist.
+// Store caches it for the negative TTL and returns it to every caller.
+var ErrNotFound = errors.New("cache: not found")
+
+// Loader fetches a value from the source of truth after a cache miss.
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// Entries are stored as a one byte kind, the logical expiry in unix
+// milliseconds (used for early refresh) and the encoded value.
+const (
+	kindValue   byte = 0
+	kindMissing byte = 1
+	headerSize       = 9
+)
+
+type storeOptions struct {
+	codec        Codec
+	ttl          time.Duration
+	jitter       float64
+	negativeTTL  time.Duration
+	refreshAhead time.Duration
+	loadTimeout  time.Duration
+	metrics      *Metrics
+}
+
+// StoreOption configures a Store.
+type StoreOption func(*storeOptions)
+
+// WithCodec sets the value encoding (default JSON).
+func WithCodec(c Codec) StoreOption {
+	return func(o *storeOptions) { o.codec = c }
+}
+
+// WithTTL sets how long values are cached (default 5m).
+func WithTTL(d time.Duration) StoreOption {
+	return func(o *storeOptions) { o.ttl = d }
+}
+
+// WithJitter spreads each TTL by ±fraction so keys written together
+// do not expire together (default 0.1).
+func WithJitter(fraction float64) StoreOption {
+	return func(o *storeOptions) { o.jitter = fraction }
+}
+
+// WithNegativeTTL sets how long ErrNotFound is cached (default 30s, 0 disables).
+func WithNegativeTTL(d time.Duration) StoreOption {
+	return func(o *storeOptions) { o.negativeTTL = d }
+}
+
+// WithRefreshAhead reloads a value in the background when a hit finds it
+// within d of expiry, so hot keys never miss. Disabled by default.
+func WithRefreshAhead(d time.Duration) StoreOption {
+	return func(o *storeOptions) { o.refreshAhead = d }
+}
+
+// WithLoadTimeout bounds each loader call (default 10s). Loads are shared
+// by all waiting callers, so they do not stop when one caller gives up.
+func WithLoadTimeout(d time.Duration) StoreOption {
+	return func(o *storeOptions) { o.loadTimeout = d }
+}
+
+// WithMetrics reports lookups and loads to m under the store's name.
+func WithMetrics(m *Metrics) StoreOption {
+	return func(o *storeOptions) { o.metrics = m }
+}
+
+// Store is a typed cache-aside helper over Redis.
+type Store[T any] struct {
+	rdb    redis.UniversalClient
+	name   string
+	opts   storeOptions
+	group  singleflight.Group
+	tracer trace.Tracer
+}
+
+// NewStore returns a Store whose keys are prefixed with "name:".
+// The name also labels metrics and spans.
+func NewStore[T any](rdb redis.UniversalClient, name string, opts ...StoreOption) *Store[T] {
+	o := storeOptions{
+		codec:       JSON,
+		ttl:         5 * time.Minute,
+		jitter:      0.1,
+		negativeTTL: 30 * time.Second,
+		loadTimeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Store[T]{
+		rdb:    rdb,
+		name:   name,
+		opts:   o,
+		tracer: otel.Tracer("helix-fnd/cache/redis"),
+	}
+}
+
+// GetOrLoad returns the cached value for key, or calls load on a miss and caches
+// the result. Concurrent misses for the same key share one load. If Redis is
+// unavailable the loader is called directly.
+func (s *Store[T]) GetOrLoad(ctx context.Context, key string, load Loader[T]) (T, error) {
+	ctx, span := s.startSpan(ctx, "cache.get_or_load", key)
+	defer span.End()
+
+	v, kind, expireAt, err := s.get(ctx, key)
+	switch {
+	case err == nil && kind == kindMissing:
+		s.result(span, ResultNegativeHit)
+		return v, ErrNotFound
+	case err == nil:
+		s.result(span, ResultHit)
+		if s.opts.refreshAhead > 0 && time.Until(expireAt) < s.opts.refreshAhead {
+			s.refresh(ctx, key, load)
+		}
+		return v, nil
+	case errors.Is(err, redis.Nil):
+		s.result(span, ResultMiss)
+	default:
+		s.result(span, ResultError)
+		span.RecordError(err)
+	}
+
+	return s.load(ctx, key, load)
+}
+
+// Set caches v under key with the store's TTL.
+func (s *Store[T]) Set(ctx context.Context, key string, v T) error {
+	data, err := s.opts.codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cache: failed to encode %s: %w", key, err)
+	}
+	return s.write(ctx, key, kindValue, data, s.opts.ttl)
+}
+
+// Delete removes keys, including cached "not found" results.
+func (s *Store[T]) Delete(ctx context.Context, keys ...string) error {
+	switch len(keys) {
+	case 0:
+		return nil
+	case 1:
+		return s.rdb.Del(ctx, s.key(keys[0])).Err()
+	}
+
+	// One DEL per key: keys in different hash slots cannot share a command on
+	// Redis Cluster, while a pipeline is split by node.
+	_, err := s.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, k := range keys {
+			p.Del(ctx, s.key(k))
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *Store[T]) key(k string) string {
+	return s.name + ":" + k
+}
+
+func (s *Store[T]) get(ctx context.Context, key string) (v T, kind byte, expireAt time.Time, err error) {
+	data, err := s.rdb.Get(ctx, s.key(key)).Bytes()
+	if err != nil {
+		return v, 0, time.Time{}, err
+	}
+	if len(data) < headerSize {
+		return v, 0, time.Time{}, fmt.Errorf("cache: malformed entry %s", key)
+	}
+
+	kind = data[0]
+	expireAt = time.UnixMilli(int64(binary.BigEndian.Uint64(data[1:headerSize])))
+	if kind == kindValue {
+		if err := s.opts.codec.Unmarshal(data[headerSize:], &v); err != nil {
+			return v, 0, time.Time{}, fmt.Errorf("cache: failed to decode %s: %w", key, err)
+		}
+	}
+	return v, kind, expireAt, nil
+}
+
+func (s *Store[T]) write(ctx context.Context, key string, kind byte, payload []byte, ttl time.Duration) error {
+	if s.opts.jitter > 0 {
+		ttl += time.Duration((rand.Float64()*2 - 1) * s.opts.jitter * float64(ttl))
+	}
+	buf := make([]byte, headerSize+len(payload))
+	buf[0] = kind
+	binary.BigEndian.PutUint64(buf[1:headerSize], uint64(time.Now().Add(ttl).UnixMilli()))
+	copy(buf[headerSize:], payload)
+
+	return s.rdb.Set(ctx, s.key(key), buf, ttl).Err()
+}
+
+// load runs the loader once per key across concurrent callers.
+func (s *Store[T]) load(ctx context.Context, key string, load Loader[T]) (T, error) {
+	ch := s.group.DoChan(key, func() (any, error) {
+		return s.loadAndStore(context.WithoutCancel(ctx), key, load)
+	})
+
+	select {
+	case res := <-ch:
+		v, _ := res.Val.(T)
+		return v, res.Err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// refresh reloads key in the background; at most one refresh per key runs at a time.
+func (s *Store[T]) refresh(ctx context.Context, key string, load Loader[T]) {
+	s.group.DoChan("\x00refresh:"+key, func() (any, error) {
+		s.opts.metrics.refresh(s.name)
+		return s.loadAndStore(context.WithoutCancel(ctx), key, load)
+	})
+}
+
+func (s *Store[T]) loadAndStore(ctx context.Context, key string, load Loader[T]) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.loadTimeout)
+	defer cancel()
+	ctx, span := s.startSpan(ctx, "cache.load", key)
+	defer span.End()
+
+	start := time.Now()
+	v, err := load(ctx)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		s.opts.metrics.load(s.name, "not_found", start)
+		if s.opts.negativeTTL > 0 {
+			if err := s.write(ctx, key, kindMissing, nil, s.opts.negativeTTL); err != nil {
+				span.RecordError(err)
+			}
+		}
+		return v, ErrNotFound
+	case err != nil:
+		s.opts.metrics.load(s.name, "error", start)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return v, err
+	}
+
+	s.opts.metrics.load(s.name, "success", start)
+	// The value is good even if caching it fails; the next caller simply loads again.
+	if err := s.Set(ctx, key, v); err != nil {
+		span.RecordError(err)
+	}
+	return v, nil
+}
+
+// startSpan follows redisTracingHook: spans only under a recording parent.
+func (s *Store[T]) startSpan(ctx context.Context, name, key string) (context.Context, trace.Span) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, noop.Span{}
+	}
+	return s.tracer.Start(ctx, name,
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("cache.name", s.name),
+			attribute.String("cache.key", key),
+		),
+	)
+}
+
+func (s *Store[T]) result(span trace.Span, result string) {
+	s.opts.metrics.request(s.name, result)
+	span.SetAttributes(attribute.String("cache.result", result))
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// The walker mirrors envconfig's naming rules exactly, so existing
+// `envconfig`, `default`, `required`, `split_words` and `ignored` tags keep
+// their meaning and every service keeps its env var names.
+var (
+	gatherRegexp  = regexp.MustCompile("([^A-Z]+|[A-Z]+[^A-Z]+|[A-Z]+)")
+	acronymRegexp = regexp.MustCompile("([A-Z]+)([A-Z][^A-Z]+)")
+)
+
+// field is a single settable leaf of a config struct.
+type field struct {
+	Path     string // Go path, e.g. "Database.DBDSN" (matches validator namespaces)
+	Key      string // Prefixed env var, e.g. "MYAPP_DATABASE_DB_DSN"
+	Alt      string // Unprefixed fallback from the envconfig tag, e.g. "DB_DSN"
+	Default  string
+	Required bool
+	Tags     reflect.StructTag
+	Value    reflect.Value
+}
+
+// gatherFields walks spec (a pointer to struct) and returns its leaves.
+// Nil pointers to structs are allocated, as envconfig does.
+func gatherFields(prefix string, spec interface{}) ([]*field, error) {
+	s := reflect.ValueOf(spec)
+	if s.Kind() != reflect.Ptr || s.Elem().Kind() != reflect.Struct {
+		return nil, envconfig.ErrInvalidSpecification
+	}
+	return walkStruct(prefix, "", s.Elem()), nil
+}
+
+func walkStruct(prefix, path string, s reflect.Value) []*field {
+	t := s.Type()
+	fields := make([]*field, 0, s.NumField())
+
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Field(i)
+		ftype := t.Field(i)
+		if !f.CanSet() || isTrue(ftype.Tag.Get("ignored")) {
+			continue
+		}
+
+		for f.Kind() == reflect.Ptr {
+			if f.IsNil() {
+				if f.Type().Elem().Kind() != reflect.Struct {
+					break
+				}
+				f.Set(reflect.New(f.Type().Elem()))
+			}
+			f = f.Elem()
+		}
+
+		info := &field{
+			Path:     joinPath(path, ftype.Name),
+			Alt:      strings.ToUpper(ftype.Tag.Get("envconfig")),
+			Default:  ftype.Tag.Get("default"),
+			Required: isTrue(ftype.Tag.Get("required")),
+			Tags:     ftype.Tag,
+			Value:    f,
+		}
+
+		key := ftype.Name
+		if isTrue(ftype.Tag.Get("split_words")) {
+			key = splitWords(ftype.Name)
+		}
+		if info.Alt != "" {
+			key = info.Alt
+		}
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		info.Key = strings.ToUpper(key)
+
+		if f.Kind() == reflect.Struct && !isDecodable(f) {
+			innerPrefix := prefix
+			if !ftype.Anonymous {
+				innerPrefix = info.Key
+			}
+			fields = append(fields, walkStruct(innerPrefix, info.Path, f)...)
+			continue
+		}
+
+		fields = append(fields, info)
+	}
+	return fields
+}
+
+func splitWords(name string) string {
+	words := gatherRegexp.FindAllStringSubmatch(name, -1)
+	if len(words) == 0 {
+		return name
+	}
+	parts := make([]string, 0, len(words))
+	for _, w := range words {
+		if m := acronymRegexp.FindStringSubmatch(w[0]); len(m) == 3 {
+			parts = append(parts, m[1], m[2])
+		} else {
+			parts = append(parts, w[0])
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// isDecodable reports whether a struct value decodes itself from a string
+// (time.Time, custom Decoder/Setter types) and must be treated as a leaf.
+func isDecodable(v reflect.Value) bool {
+	if !v.CanAddr() {
+		return false
+	}
+	switch v.Addr().Interface().(type) {
+	case envconfig.Decoder, envconfig.Setter, encoding.TextUnmarshaler, encoding.BinaryUnmarshaler:
+		return true
+	}
+	return false
+}
+
+// setField parses value into v using the same rules as envconfig.
+func setField(value string, v reflect.Value) error {
+	if v.CanAddr() {
+		switch d := v.Addr().Interface().(type) {
+		case envconfig.Decoder:
+			return d.Decode(value)
+		case envconfig.Setter:
+			return d.Set(value)
+		case encoding.TextUnmarshaler:
+			return d.UnmarshalText([]byte(value))
+		case encoding.BinaryUnmarshaler:
+			return d.UnmarshalBinary([]byte(value))
+		}
+	}
+
+	typ := v.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+		if v.IsNil() {
+			v.Set(reflect.New(typ))
+		}
+		v = v.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if typ == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, typ.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		return setSlice(value, typ, v)
+	case reflect.Map:
+		return setMap(value, typ, v)
+	default:
+		return fmt.Errorf("unsupported type %s", typ)
+	}
+	return nil
+}
+
+func setSlice(value string, typ reflect.Type, v reflect.Value) error {
+	if typ.Elem().Kind() == reflect.Uint8 {
+		v.Set(reflect.ValueOf([]byte(value)).Convert(typ))
+		return nil
+	}
+	sl := reflect.MakeSlice(typ, 0, 0)
+	if strings.TrimSpace(value) != "" {
+		parts := strings.Split(value, ",")
+		sl = reflect.MakeSlice(typ, len(parts), len(parts))
+		for i, part := range parts {
+			if err := setField(part, sl.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	v.Set(sl)
+	return nil
+}
+
+func setMap(value string, typ reflect.Type, v reflect.Value) error {
+	mp := reflect.MakeMap(typ)
+	if strings.TrimSpace(value) != "" {
+		for _, pair := range strings.Split(value, ",") {
+			kv := strings.Split(pair, ":")
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid map item: %q", pair)
+			}
+			k := reflect.New(typ.Key()).Elem()
+			if err := setField(kv[0], k); err != nil {
+				return err
+			}
+			e := reflect.New(typ.Elem()).Elem()
+			if err := setField(kv[1], e); err != nil {
+				return err
+			}
+			mp.SetMapIndex(k, e)
+		}
+	}
+	v.Set(mp)
+	return nil
+}
+
+func isTrue(s string) bool {
+	b, _ := strconv.ParseBool(s)
+	return b
+}
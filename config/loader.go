@@ -1,52 +1,329 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
 
-	"github.com/kelseyhightower/envconfig"
-	"gopkg.in/yaml.v3"
+	"github.com/go-playground/validator/v10"
 )
 
-// Loader handles configuration loading from YAML and Environment variables.
-// Priority: Env Vars > YAML > Defaults.
-// This loader is immutable. It runs once at startup.
+// Loader handles configuration loading from layered sources.
+// Priority (highest first): Env Vars > .env files > YAML overlay > base YAML > `default` tags.
+// The result is validated with validator/v10 `validate` tags.
+// Load itself is stateless; wrap the loader in a Reloader to pick up changes at runtime.
 type Loader[T any] struct {
-	envPrefix  string
-	configPath string
+	envPrefix string
+	opts      *options
 }
 
-func NewLoader[T any](envPrefix, configPath string) *Loader[T] {
+func NewLoader[T any](envPrefix, configPath string, opts ...Option) *Loader[T] {
+	if configPath != "" {
+		// The base file always comes first, before any WithFile layers.
+		opts = append([]Option{WithFile(configPath)}, opts...)
+	}
+	o := newOptions(opts)
+	if o.validate == nil {
+		o.validate = validator.New()
+	}
+	if o.secrets == nil {
+		// Long-lived so the TTL cache is shared across reloads.
+		o.secrets = NewSecrets(0)
+	}
+
 	return &Loader[T]{
-		envPrefix:  envPrefix,
-		configPath: configPath,
+		envPrefix: envPrefix,
+		opts:      o,
 	}
 }
 
 // Load reads the configuration.
 func (l *Loader[T]) Load() (*T, error) {
+	cfg, _, err := l.LoadWithReport(context.Background())
+	return cfg, err
+}
+
+// LoadWithReport reads the configuration and describes where each value came from.
+// The report never contains secret values, so it is the safe thing to log or dump.
+func (l *Loader[T]) LoadWithReport(ctx context.Context) (*T, *Report, error) {
 	var cfg T
+	report, err := l.opts.process(ctx, l.envPrefix, &cfg)
+	if err != nil {
+		return nil, report, err
+	}
+	return &cfg, report, nil
+}
+
+// files returns the files whose content affects Load. Used by Reloader for change detection.
+func (l *Loader[T]) files() []string {
+	return append(l.opts.yamlFiles(), l.opts.dotEnvFiles...)
+}
+
+// Process loads spec (a pointer to struct) from every configured layer and validates it.
+// It is the non-generic core shared by Loader[T] and app.Loader.
+func Process(ctx context.Context, prefix string, spec interface{}, opts ...Option) (*Report, error) {
+	return newOptions(opts).process(ctx, prefix, spec)
+}
+
+// Option customizes the sources used by Process and Loader.
+type Option func(*options)
+
+type options struct {
+	files       []string
+	environment string
+	dotEnvFiles []string
+	validate    *validator.Validate
+	secrets     *Secrets
+	strict      bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithFile adds a YAML layer. Later layers override earlier ones; missing files are skipped.
+func WithFile(path string) Option {
+	return func(o *options) {
+		if path != "" {
+			o.files = append(o.files, path)
+		}
+	}
+}
+
+// WithEnvironment adds an overlay next to every YAML layer:
+// with env "prod", config.yaml is followed by config.prod.yaml.
+func WithEnvironment(env string) Option {
+	return func(o *options) { o.environment = env }
+}
 
-	// 1. Load from YAML if exists
-	if l.configPath != "" {
-		if _, err := os.Stat(l.configPath); err == nil {
-			file, err := os.Open(l.configPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to open config file: %w", err)
-			}
-			defer file.Close()
+// WithDotEnv reads KEY=VALUE files. Real environment variables always win over them.
+func WithDotEnv(paths ...string) Option {
+	return func(o *options) { o.dotEnvFiles = append(o.dotEnvFiles, paths...) }
+}
+
+// WithSecrets replaces the default secret resolvers (file, env, secretref).
+func WithSecrets(s *Secrets) Option {
+	return func(o *options) { o.secrets = s }
+}
+
+// WithStrict rejects YAML keys that match no field and prefixed env vars
+// (e.g. MYAPP_DB_MAX_OPEN_CON) that match no key, suggesting the closest valid name.
+// Env checking needs a non-empty prefix; unprefixed fallbacks cannot be told apart from
+// unrelated variables.
+func WithStrict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// WithValidator replaces the default validator, e.g. to register custom tags.
+func WithValidator(v *validator.Validate) Option {
+	return func(o *options) { o.validate = v }
+}
+
+func (o *options) yamlFiles() []string {
+	files := make([]string, 0, len(o.files)*2)
+	for _, f := range o.files {
+		files = append(files, f)
+		if o.environment != "" {
+			files = append(files, overlayPath(f, o.environment))
+		}
+	}
+	return files
+}
+
+func (o *options) process(ctx context.Context, prefix string, spec interface{}) (*Report, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fields, err := gatherFields(prefix, spec)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
 
-			decoder := yaml.NewDecoder(file)
-			if err := decoder.Decode(&cfg); err != nil {
-				return nil, fmt.Errorf("failed to decode config file: %w", err)
-			}
+	sources := make(map[string]string, len(fields))
+	var problems []Problem
+
+	// 1. Defaults from `default` tags
+	for _, f := range fields {
+		if f.Default == "" {
+			continue
+		}
+		if err := setField(f.Default, f.Value); err != nil {
+			problems = append(problems, Problem{Path: f.Path, Key: f.Key, Source: SourceDefault, Message: err.Error()})
+			continue
 		}
+		sources[f.Path] = SourceDefault
 	}
 
-	// 2. Override with Environment Variables
-	if err := envconfig.Process(l.envPrefix, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to process env vars: %w", err)
+	// 2. YAML layers, in order
+	for _, path := range o.yamlFiles() {
+		data, ok, err := readOptional(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		layer, err := decodeYAML(data, spec)
+		if err != nil {
+			return nil, fmt.Errorf("config: failed to decode %s: %w", path, err)
+		}
+		for p := range layer.set {
+			sources[p] = fileSource(path)
+		}
+		if o.strict {
+			problems = append(problems, unknownYAMLProblems(path, layer.unknown)...)
+		}
 	}
 
-	return &cfg, nil
+	// 3. .env files, then real environment variables
+	env, err := o.environ()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		value, src, ok := env.lookup(f)
+		if !ok {
+			continue
+		}
+		if err := setField(value, f.Value); err != nil {
+			problems = append(problems, Problem{Path: f.Path, Key: f.Key, Source: src, Message: parseMessage(f, err)})
+			continue
+		}
+		sources[f.Path] = src
+	}
+
+	if o.strict {
+		problems = append(problems, unknownEnvProblems(prefix, fields, env)...)
+	}
+
+	// 4. Required fields must be set by some layer
+	for _, f := range fields {
+		if f.Required && sources[f.Path] == "" {
+			problems = append(problems, Problem{Path: f.Path, Key: f.Key, Source: SourceUnset, Message: "required value missing"})
+		}
+	}
+
+	// 5. Resolve secret references (file://, env://, secretref://) in whatever layer set them
+	secrets := o.secrets
+	if secrets == nil {
+		secrets = NewSecrets(0)
+	}
+	resolved := make(map[string]bool)
+	for _, f := range fields {
+		ok, err := resolveSecret(ctx, secrets, f)
+		if err != nil {
+			problems = append(problems, Problem{Path: f.Path, Key: f.Key, Source: sources[f.Path], Message: err.Error()})
+			continue
+		}
+		if ok {
+			resolved[f.Path] = true
+		}
+	}
+
+	report := newReport(prefix, fields, sources, resolved)
+	if len(problems) > 0 {
+		return report, &Error{Problems: problems}
+	}
+
+	// 6. Validate constraints (min, max, oneof, etc.)
+	validate := o.validate
+	if validate == nil {
+		validate = validator.New()
+	}
+	if err := validate.Struct(spec); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			return report, fmt.Errorf("config: validation failed: %w", err)
+		}
+		return report, validationError(verrs, report)
+	}
+
+	return report, nil
+}
+
+// environ merges the .env files; OS variables are looked up live and take precedence.
+func (o *options) environ() (*environment, error) {
+	e := &environment{dotEnv: make(map[string]dotEnvValue)}
+	for _, path := range o.dotEnvFiles {
+		data, ok, err := readOptional(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		vars, err := parseDotEnv(data)
+		if err != nil {
+			return nil, fmt.Errorf("config: failed to parse %s: %w", path, err)
+		}
+		for k, v := range vars {
+			e.dotEnv[k] = dotEnvValue{value: v, path: path}
+		}
+	}
+	return e, nil
+}
+
+type dotEnvValue struct {
+	value string
+	path  string
+}
+
+type environment struct {
+	dotEnv map[string]dotEnvValue
+}
+
+// lookup mirrors envconfig: the prefixed key first, then the unprefixed tag name.
+func (e *environment) lookup(f *field) (value, source string, ok bool) {
+	keys := []string{f.Key}
+	if f.Alt != "" && f.Alt != f.Key {
+		keys = append(keys, f.Alt)
+	}
+	for _, k := range keys {
+		if v, ok := os.LookupEnv(k); ok {
+			return v, envSource(k), true
+		}
+	}
+	for _, k := range keys {
+		if v, ok := e.dotEnv[k]; ok {
+			return v.value, dotEnvSource(v.path, k), true
+		}
+	}
+	return "", "", false
+}
+
+// resolveSecret swaps a reference for the secret it points to.
+// Only string fields are considered; tag a field `secret:"-"` to opt out.
+func resolveSecret(ctx context.Context, secrets *Secrets, f *field) (bool, error) {
+	v := f.Value
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String || f.Tags.Get("secret") == "-" {
+		return false, nil
+	}
+
+	value, ok, err := secrets.Resolve(ctx, v.String())
+	if !ok || err != nil {
+		return ok, err
+	}
+	v.SetString(value)
+	return true, nil
+}
+
+// parseMessage hides the raw value of secret fields; strconv errors quote their input.
+func parseMessage(f *field, err error) string {
+	if isTrue(f.Tags.Get("secret")) {
+		return fmt.Sprintf("cannot parse value as %s", f.Value.Type())
+	}
+	return err.Error()
 }
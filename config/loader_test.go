@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type layeredConfig struct {
+	Name    string        `envconfig:"NAME" default:"from-default"`
+	Port    int           `envconfig:"PORT" default:"1"`
+	Timeout time.Duration `envconfig:"TIMEOUT" default:"1s"`
+	Level   string        `envconfig:"LEVEL" default:"info"`
+	Region  string        `envconfig:"REGION" default:"eu"`
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoaderLayerPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.yaml", "name: from-base\nport: 2\ntimeout: 2s\nlevel: base\n")
+	writeFile(t, dir, "config.prod.yaml", "port: 3\ntimeout: 3s\nlevel: overlay\n")
+	dotEnv := writeFile(t, dir, ".env", "HLXTEST_TIMEOUT=4s\nHLXTEST_LEVEL=dotenv\n")
+	t.Setenv("HLXTEST_LEVEL", "env")
+
+	cfg, report, err := NewLoader[layeredConfig]("HLXTEST", base,
+		WithEnvironment("prod"), WithDotEnv(dotEnv)).LoadWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("LoadWithReport: %v", err)
+	}
+
+	want := layeredConfig{Name: "from-base", Port: 3, Timeout: 4 * time.Second, Level: "env", Region: "eu"}
+	if *cfg != want {
+		t.Errorf("config = %+v, want %+v", *cfg, want)
+	}
+
+	sources := map[string]string{
+		"Region":  SourceDefault,
+		"Name":    fileSource(base),
+		"Port":    fileSource(filepath.Join(dir, "config.prod.yaml")),
+		"Timeout": dotEnvSource(dotEnv, "HLXTEST_TIMEOUT"),
+		"Level":   envSource("HLXTEST_LEVEL"),
+	}
+	for path, src := range sources {
+		info, ok := report.Field(path)
+		if !ok {
+			t.Fatalf("report has no field %s", path)
+		}
+		if info.Source != src {
+			t.Errorf("%s source = %q, want %q", path, info.Source, src)
+		}
+	}
+}
+
+func TestLoaderInvalidValue(t *testing.T) {
+	t.Setenv("HLXTEST_PORT", "abc")
+
+	_, err := NewLoader[layeredConfig]("HLXTEST", "").Load()
+	var cerr *Error
+	if !errors.As(err, &cerr) || len(cerr.Problems) != 1 {
+		t.Fatalf("Load error = %v, want one problem", err)
+	}
+	if p := cerr.Problems[0]; p.Path != "Port" || p.Source != envSource("HLXTEST_PORT") {
+		t.Errorf("problem = %+v, want Port from env HLXTEST_PORT", p)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON writes the report as indented JSON. Secrets are already redacted.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteMarkdown writes the report as a Markdown table, ready for a README or runbook.
+// Value and Source columns are included only for loaded reports.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	withValues := r.loaded()
+
+	var b strings.Builder
+	if withValues {
+		b.WriteString("| Env Variable | Type | Default | Required | Value | Source | Description |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	} else {
+		b.WriteString("| Env Variable | Type | Default | Required | Description |\n")
+		b.WriteString("| --- | --- | --- | --- | --- |\n")
+	}
+
+	for _, f := range r.Fields {
+		key := "`" + f.Key + "`"
+		if f.Alt != "" {
+			key += " (or `" + f.Alt + "`)"
+		}
+		cells := []string{key, "`" + f.Type + "`", mdCode(f.Default), mdBool(f.Required)}
+		if withValues {
+			cells = append(cells, mdCode(f.Value), f.Source)
+		}
+		cells = append(cells, f.Description)
+
+		for i := range cells {
+			cells[i] = strings.ReplaceAll(cells[i], "|", "\\|")
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mdCode(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return "`" + s + "`"
+}
+
+func mdBool(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
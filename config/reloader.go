@@ -0,0 +1,255 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const defaultPollInterval = 10 * time.Second
+
+// Reloader holds the latest valid configuration and republishes it when the
+// config file changes (polling) or the process receives SIGHUP.
+//
+// An invalid config is rejected and logged; the running config stays in place.
+// Current() is lock-free and safe for hot paths.
+type Reloader[T any] struct {
+	loader   *Loader[T]
+	interval time.Duration
+	logger   *slog.Logger
+
+	current atomic.Pointer[T]
+
+	// mu serializes reloads so subscribers see changes in order.
+	mu   sync.Mutex
+	sums map[string][sha256.Size]byte
+
+	subsMu     sync.Mutex
+	subs       map[uint64]func(old, new *T)
+	validators map[uint64]func(*T) error
+	nextID     uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewReloader performs the initial load and fails fast if it is invalid.
+// A zero interval defaults to 10s; a negative interval disables polling (SIGHUP only).
+func NewReloader[T any](loader *Loader[T], interval time.Duration, logger *slog.Logger) (*Reloader[T], error) {
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	r := &Reloader[T]{
+		loader:     loader,
+		interval:   interval,
+		logger:     logger.With("component", "config_reloader"),
+		subs:       make(map[uint64]func(old, new *T)),
+		validators: make(map[uint64]func(*T) error),
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	r.sums = r.checksums()
+	r.current.Store(cfg)
+
+	return r, nil
+}
+
+// Current returns the active configuration. Treat it as read-only.
+func (r *Reloader[T]) Current() *T {
+	return r.current.Load()
+}
+
+// Subscribe registers fn to be called after every accepted change.
+// Callbacks run synchronously on the reload goroutine; their relative order is not guaranteed.
+// The returned function removes the subscription.
+func (r *Reloader[T]) Subscribe(fn func(old, new *T)) (unsubscribe func()) {
+	r.subsMu.Lock()
+	defer r.subsMu.Unlock()
+
+	id := r.nextID
+	r.nextID++
+	r.subs[id] = fn
+
+	return func() {
+		r.subsMu.Lock()
+		delete(r.subs, id)
+		r.subsMu.Unlock()
+	}
+}
+
+// AddValidator registers fn to check every reloaded config before it is
+// published. A config fn rejects is treated like one that fails validation:
+// it is logged and the current one is kept. Use it when a subscriber derives
+// state that can fail to build, so Current() never runs ahead of it.
+// The returned function removes the validator.
+func (r *Reloader[T]) AddValidator(fn func(*T) error) (remove func()) {
+	r.subsMu.Lock()
+	defer r.subsMu.Unlock()
+
+	id := r.nextID
+	r.nextID++
+	r.validators[id] = fn
+
+	return func() {
+		r.subsMu.Lock()
+		delete(r.validators, id)
+		r.subsMu.Unlock()
+	}
+}
+
+// OnChange subscribes to a single part of the config. fn only fires when the
+// value returned by pick actually differs between the old and new config.
+//
+//	config.OnChange(reloader, func(c *Config) int { return c.RateLimit.RPS }, func(_, rps int) {
+//		limiter.SetLimit(rate.Limit(rps))
+//	})
+func OnChange[T, V any](r *Reloader[T], pick func(*T) V, fn func(old, new V)) (unsubscribe func()) {
+	return r.Subscribe(func(oldCfg, newCfg *T) {
+		oldV, newV := pick(oldCfg), pick(newCfg)
+		if !reflect.DeepEqual(oldV, newV) {
+			fn(oldV, newV)
+		}
+	})
+}
+
+// Reload re-runs the YAML + env merge and validation.
+// On success the new config is published; on failure the current one is kept.
+func (r *Reloader[T]) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sums = r.checksums()
+
+	next, err := r.loader.Load()
+	if err != nil {
+		r.logger.Error("Config reload rejected, keeping current config", "error", err)
+		return fmt.Errorf("config: reload rejected: %w", err)
+	}
+
+	prev := r.current.Load()
+	if reflect.DeepEqual(prev, next) {
+		return nil
+	}
+
+	r.subsMu.Lock()
+	validators := make([]func(*T) error, 0, len(r.validators))
+	for _, fn := range r.validators {
+		validators = append(validators, fn)
+	}
+	subs := make([]func(old, new *T), 0, len(r.subs))
+	for _, fn := range r.subs {
+		subs = append(subs, fn)
+	}
+	r.subsMu.Unlock()
+
+	for _, fn := range validators {
+		if err := fn(next); err != nil {
+			r.logger.Error("Config reload rejected, keeping current config", "error", err)
+			return fmt.Errorf("config: reload rejected: %w", err)
+		}
+	}
+
+	r.current.Store(next)
+	r.logger.Info("Config reloaded")
+
+	for _, fn := range subs {
+		fn(prev, next)
+	}
+	return nil
+}
+
+// Start watches for SIGHUP and file changes until Stop is called.
+func (r *Reloader[T]) Start(ctx context.Context) error {
+	if r.done != nil {
+		return errors.New("config: reloader already started")
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go r.watch(ctx)
+	return nil
+}
+
+// Stop ends the watch loop.
+func (r *Reloader[T]) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Reloader[T]) watch(ctx context.Context) {
+	defer close(r.done)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if r.interval > 0 && len(r.loader.files()) > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.logger.Info("SIGHUP received, reloading config")
+			_ = r.Reload()
+		case <-tick:
+			if r.changed() {
+				_ = r.Reload()
+			}
+		}
+	}
+}
+
+// changed compares file contents rather than mtimes: ConfigMap volumes swap
+// symlinks and editors rewrite files, both of which make mtimes unreliable.
+func (r *Reloader[T]) changed() bool {
+	sums := r.checksums()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !reflect.DeepEqual(sums, r.sums)
+}
+
+func (r *Reloader[T]) checksums() map[string][sha256.Size]byte {
+	sums := make(map[string][sha256.Size]byte)
+	for _, path := range r.loader.files() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// Missing file is a valid state (YAML is optional); zero sum tracks it.
+			sums[path] = [sha256.Size]byte{}
+			continue
+		}
+		sums[path] = sha256.Sum256(data)
+	}
+	return sums
+}
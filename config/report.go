@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Report describes where each configuration value came from.
+type Report struct {
+	Prefix string      `json:"prefix"`
+	Fields []FieldInfo `json:"fields"`
+}
+
+// FieldInfo describes one leaf of the config struct.
+type FieldInfo struct {
+	Path        string `json:"path"`          // Go path, e.g. "Database.DBDSN"
+	Key         string `json:"key"`           // Env var, e.g. "MYAPP_DATABASE_DB_DSN"
+	Alt         string `json:"alt,omitempty"` // Unprefixed fallback, e.g. "DB_DSN"
+	Type        string `json:"type"`          // Go type, e.g. "time.Duration"
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"` // From the `desc` tag
+	Source      string `json:"source,omitempty"`      // "default", "file config.yaml", "env MYAPP_DATABASE_DB_DSN", ...
+	Value       string `json:"value,omitempty"`       // Effective value, Redacted for secrets
+	Secret      bool   `json:"secret"`                // Tagged `secret:"true"` or resolved from a secret reference
+}
+
+// newReport builds a report. A nil sources map means nothing was loaded
+// (Describe), so Source and Value are left empty.
+func newReport(prefix string, fields []*field, sources map[string]string, resolved map[string]bool) *Report {
+	r := &Report{Prefix: prefix, Fields: make([]FieldInfo, 0, len(fields))}
+	for _, f := range fields {
+		info := FieldInfo{
+			Path:        f.Path,
+			Key:         f.Key,
+			Type:        f.Value.Type().String(),
+			Default:     f.Default,
+			Required:    f.Required,
+			Description: f.Tags.Get("desc"),
+			Secret:      resolved[f.Path] || isTrue(f.Tags.Get("secret")),
+		}
+		if f.Alt != f.Key {
+			info.Alt = f.Alt
+		}
+
+		if sources != nil {
+			info.Source = sources[f.Path]
+			if info.Source == "" {
+				info.Source = SourceUnset
+			}
+			if info.Secret {
+				info.Value = Redacted
+			} else {
+				info.Value = formatValue(f.Value)
+			}
+		}
+		r.Fields = append(r.Fields, info)
+	}
+	return r
+}
+
+// Describe lists every setting of spec without loading anything.
+// Use it to generate an env var reference.
+func Describe(prefix string, spec interface{}) (*Report, error) {
+	fields, err := gatherFields(prefix, spec)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return newReport(prefix, fields, nil, nil), nil
+}
+
+func (r *Report) loaded() bool {
+	for _, f := range r.Fields {
+		if f.Source != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// LogValue lets a Report be passed straight to slog: one attribute per env key,
+// with secrets already redacted.
+func (r *Report) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(r.Fields))
+	for _, f := range r.Fields {
+		attrs = append(attrs, slog.String(f.Key, f.Value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// formatValue renders a field the way it would be written in an env var.
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.CanInterface() {
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Map:
+		parts := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			parts = append(parts, formatValue(iter.Key())+":"+formatValue(iter.Value()))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Field looks up a field by Go path.
+func (r *Report) Field(path string) (FieldInfo, bool) {
+	for _, f := range r.Fields {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+// Problem is a single bad or missing value.
+type Problem struct {
+	Path    string
+	Key     string
+	Source  string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Key == "" {
+		return fmt.Sprintf("%s (from %s): %s", p.Path, p.Source, p.Message)
+	}
+	return fmt.Sprintf("%s (%s, from %s): %s", p.Path, p.Key, p.Source, p.Message)
+}
+
+// Error collects every problem found during a load, so operators can fix
+// a broken deployment in one pass instead of one restart per typo.
+type Error struct {
+	Problems []Problem
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "config: %d invalid value(s):", len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+func validationError(verrs validator.ValidationErrors, report *Report) *Error {
+	problems := make([]Problem, 0, len(verrs))
+	for _, fe := range verrs {
+		// StructNamespace is "Config.Database.DBDSN"; drop the root type name.
+		path := fe.StructNamespace()
+		if _, rest, ok := strings.Cut(path, "."); ok {
+			path = rest
+		}
+
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+
+		p := Problem{Path: path, Source: SourceUnset, Message: fmt.Sprintf("failed %q validation", rule)}
+		if info, ok := report.Field(path); ok {
+			p.Key = info.Key
+			p.Source = info.Source
+		}
+		problems = append(problems, p)
+	}
+	return &Error{Problems: problems}
+}
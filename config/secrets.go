@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces secret values in reports and logs.
+const Redacted = "[REDACTED]"
+
+const (
+	defaultSecretTTL = 1 * time.Minute
+	defaultSecretDir = "/run/secrets"
+)
+
+// SecretResolver resolves the name part of a secret reference to its value.
+type SecretResolver interface {
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// FileResolver reads a secret from a file (Docker/Kubernetes secret mounts).
+// Relative names are resolved under Dir. One trailing newline is trimmed.
+type FileResolver struct {
+	Dir string
+}
+
+func (r FileResolver) Resolve(_ context.Context, name string) (string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.Dir, filepath.Clean("/"+path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// EnvResolver reads a secret from another environment variable.
+type EnvResolver struct{}
+
+func (EnvResolver) Resolve(_ context.Context, name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+// Secrets routes "scheme://name" references to resolvers and caches results.
+// Built-in schemes:
+//
+//	file:///run/secrets/db_dsn  -> FileResolver (absolute path)
+//	env://DB_PASSWORD           -> EnvResolver
+//	secretref://db_dsn          -> FileResolver{Dir: "/run/secrets"}
+//
+// Register replaces or adds schemes, e.g. a Vault or cloud secret manager client.
+type Secrets struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	resolvers map[string]SecretResolver
+	cache     map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// NewSecrets creates a resolver set with the built-in schemes.
+// ttl bounds how long a resolved value is reused; zero defaults to one minute,
+// negative disables caching. Keep it short if secrets rotate and config is reloaded.
+func NewSecrets(ttl time.Duration) *Secrets {
+	if ttl == 0 {
+		ttl = defaultSecretTTL
+	}
+	return &Secrets{
+		ttl: ttl,
+		resolvers: map[string]SecretResolver{
+			"file":      FileResolver{},
+			"env":       EnvResolver{},
+			"secretref": FileResolver{Dir: defaultSecretDir},
+		},
+		cache: make(map[string]cachedSecret),
+	}
+}
+
+// Register binds a scheme (without "://") to a resolver.
+func (s *Secrets) Register(scheme string, r SecretResolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resolvers[strings.ToLower(scheme)] = r
+}
+
+// Resolve returns the secret value if ref is a reference to a registered scheme.
+// ok is false for plain values, which are left untouched.
+func (s *Secrets) Resolve(ctx context.Context, ref string) (value string, ok bool, err error) {
+	scheme, name, found := strings.Cut(ref, "://")
+	if !found {
+		return "", false, nil
+	}
+
+	s.mu.Lock()
+	resolver, registered := s.resolvers[strings.ToLower(scheme)]
+	cached, hit := s.cache[ref]
+	s.mu.Unlock()
+
+	if !registered {
+		return "", false, nil
+	}
+	if hit && time.Now().Before(cached.expires) {
+		return cached.value, true, nil
+	}
+
+	value, err = resolver.Resolve(ctx, name)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to resolve %s secret: %w", scheme, err)
+	}
+
+	if s.ttl > 0 {
+		s.mu.Lock()
+		s.cache[ref] = cachedSecret{value: value, expires: time.Now().Add(s.ttl)}
+		s.mu.Unlock()
+	}
+	return value, true, nil
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source labels, used in load reports and error messages.
+const (
+	SourceDefault = "default"
+	SourceUnset   = "unset"
+)
+
+func fileSource(path string) string        { return "file " + path }
+func envSource(key string) string          { return "env " + key }
+func dotEnvSource(path, key string) string { return "dotenv " + path + " (" + key + ")" }
+
+// overlayPath turns "config.yaml" into "config.<env>.yaml".
+func overlayPath(path, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// readOptional reads a file, treating "does not exist" as absent rather than an error.
+func readOptional(path string) ([]byte, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, true, nil
+}
+
+// yamlLayer is the outcome of decoding one YAML file.
+type yamlLayer struct {
+	set     map[string]bool // Go paths touched by the document
+	unknown []unknownKey    // keys that match no struct field
+}
+
+type unknownKey struct {
+	path       string // YAML path, e.g. "db.dbmaxcon"
+	line       int
+	suggestion string
+}
+
+// decodeYAML applies one YAML layer onto spec.
+func decodeYAML(data []byte, spec interface{}) (*yamlLayer, error) {
+	layer := &yamlLayer{set: make(map[string]bool)}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return layer, nil // empty document
+	}
+	if err := root.Decode(spec); err != nil {
+		return nil, err
+	}
+
+	layer.mark(root.Content[0], reflect.TypeOf(spec), "", "")
+	return layer, nil
+}
+
+// mark walks a YAML mapping alongside the struct type, recording the Go path
+// of every field the document touches and every key that matches nothing.
+func (l *yamlLayer) mark(node *yaml.Node, t reflect.Type, path, ypath string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind != yaml.MappingNode || t.Kind() != reflect.Struct || isDecodableType(t) {
+		if path != "" {
+			l.set[path] = true
+		}
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, val := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		sf, fpath, ok := yamlField(t, key, path)
+		if !ok {
+			l.unknown = append(l.unknown, unknownKey{
+				path:       joinPath(ypath, key),
+				line:       keyNode.Line,
+				suggestion: closest(key, yamlNames(t)),
+			})
+			continue
+		}
+		l.mark(val, sf.Type, fpath, joinPath(ypath, key))
+	}
+}
+
+// yamlField resolves a YAML key to a struct field using yaml.v3 naming rules,
+// descending into ",inline" fields.
+func yamlField(t reflect.Type, key, path string) (reflect.StructField, string, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name, inline := yamlName(sf)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			inner := sf.Type
+			for inner.Kind() == reflect.Ptr {
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				if f, p, ok := yamlField(inner, key, joinPath(path, sf.Name)); ok {
+					return f, p, true
+				}
+			}
+			continue
+		}
+		if name == key {
+			return sf, joinPath(path, sf.Name), true
+		}
+	}
+	return reflect.StructField{}, "", false
+}
+
+// yamlNames lists the keys a struct accepts, including inlined ones.
+func yamlNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name, inline := yamlName(sf)
+		switch {
+		case name == "-":
+		case inline:
+			inner := sf.Type
+			for inner.Kind() == reflect.Ptr {
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				names = append(names, yamlNames(inner)...)
+			}
+		default:
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func yamlName(sf reflect.StructField) (name string, inline bool) {
+	tag := sf.Tag.Get("yaml")
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+	name = parts[0]
+	if name == "" {
+		name = strings.ToLower(sf.Name)
+	}
+	return name, inline
+}
+
+func isDecodableType(t reflect.Type) bool {
+	return isDecodable(reflect.New(t).Elem())
+}
+
+// parseDotEnv reads KEY=VALUE lines. Supports comments, blank lines,
+// an optional "export " prefix and single or double quoted values.
+func parseDotEnv(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			// Strip trailing inline comments on unquoted values
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+		vars[key] = value
+	}
+	return vars, scanner.Err()
+}
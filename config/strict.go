@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+func unknownYAMLProblems(file string, keys []unknownKey) []Problem {
+	problems := make([]Problem, 0, len(keys))
+	for _, k := range keys {
+		problems = append(problems, Problem{
+			Path:    k.path,
+			Source:  fmt.Sprintf("%s:%d", fileSource(file), k.line),
+			Message: "unknown key" + didYouMean(k.suggestion),
+		})
+	}
+	return problems
+}
+
+// unknownEnvProblems reports PREFIX_* variables from the OS environment and
+// .env files that no field reads.
+func unknownEnvProblems(prefix string, fields []*field, env *environment) []Problem {
+	if prefix == "" {
+		return nil
+	}
+	want := strings.ToUpper(prefix) + "_"
+
+	known := make(map[string]bool, len(fields))
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		known[f.Key] = true
+		keys = append(keys, f.Key)
+	}
+
+	sources := make(map[string]string)
+	for name, v := range env.dotEnv {
+		sources[name] = dotEnvSource(v.path, name)
+	}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		sources[name] = envSource(name)
+	}
+
+	var problems []Problem
+	for name, src := range sources {
+		if !strings.HasPrefix(name, want) || known[name] {
+			continue
+		}
+		problems = append(problems, Problem{
+			Path:    name,
+			Source:  src,
+			Message: "unknown variable" + didYouMean(closest(name, keys)),
+		})
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Path < problems[j].Path })
+	return problems
+}
+
+func didYouMean(s string) string {
+	if s == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", s)
+}
+
+// closest returns the candidate with the smallest edit distance to name,
+// or "" if nothing is close enough to be a plausible typo.
+func closest(name string, candidates []string) string {
+	best, bestDist := "", -1
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(name), strings.ToLower(c))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+
+	limit := len(name) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	if bestDist < 0 || bestDist > limit {
+		return ""
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
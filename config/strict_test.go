@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoaderStrict(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.yaml", "name: ok\nprot: 2\n")
+	t.Setenv("HLXTEST_TIMEOTU", "5s")
+
+	// Without strict mode, unknown keys and variables are ignored.
+	if _, err := NewLoader[layeredConfig]("HLXTEST", base).Load(); err != nil {
+		t.Fatalf("non-strict Load: %v", err)
+	}
+
+	_, err := NewLoader[layeredConfig]("HLXTEST", base, WithStrict()).Load()
+	var cerr *Error
+	if !errors.As(err, &cerr) {
+		t.Fatalf("strict Load error = %v, want *Error", err)
+	}
+
+	want := map[string]string{
+		"prot":            `unknown key, did you mean "port"?`,
+		"HLXTEST_TIMEOTU": `unknown variable, did you mean "HLXTEST_TIMEOUT"?`,
+	}
+	if len(cerr.Problems) != len(want) {
+		t.Fatalf("problems = %v, want %d", cerr.Problems, len(want))
+	}
+	for _, p := range cerr.Problems {
+		if msg, ok := want[p.Path]; !ok || p.Message != msg {
+			t.Errorf("problem %s: %q, want %q", p.Path, p.Message, msg)
+		}
+		if p.Path == "prot" && !strings.HasSuffix(p.Source, ":2") {
+			t.Errorf("yaml problem source = %q, want line 2", p.Source)
+		}
+	}
+}
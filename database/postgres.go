@@ -14,14 +14,18 @@ import (
 )
 
 // Config holds standard database configuration.
+//
+// DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME are crossed: the first sets the
+// idle time, the second the lifetime. The names are kept so deployed values keep
+// their effect; the descriptions say what each one really sets.
 type Config struct {
-	DBDSN             string        `envconfig:"DB_DSN" required:"true"`
-	DBMaxConns        int32         `envconfig:"DB_MAX_OPEN_CONNS" default:"50"`
-	DBMinConns        int32         `envconfig:"DB_MIN_IDLE_CONNS" default:"10"`
-	DBMaxConnIdle     time.Duration `envconfig:"DB_CONN_MAX_LIFETIME" default:"30m"`
-	DBMaxConnLife     time.Duration `envconfig:"DB_CONN_MAX_IDLE_TIME" default:"15m"`
-	DBConnectTimeout  time.Duration `envconfig:"DB_CONN_TIMEOUT" default:"15m"`
-	HealthCheckPeriod time.Duration `envconfig:"DB_HEALTHCHECK_PERIOD" default:"1m"`
+	DBDSN             string        `envconfig:"DB_DSN" required:"true" secret:"true" desc:"PostgreSQL connection string (DSN)"`
+	DBMaxConns        int32         `envconfig:"DB_MAX_OPEN_CONNS" default:"50" desc:"Maximum pool size"`
+	DBMinConns        int32         `envconfig:"DB_MIN_IDLE_CONNS" default:"10" desc:"Minimum idle connections kept open"`
+	DBMaxConnIdle     time.Duration `envconfig:"DB_CONN_MAX_LIFETIME" default:"30m" desc:"Maximum connection idle time (despite the name; see Config)"`
+	DBMaxConnLife     time.Duration `envconfig:"DB_CONN_MAX_IDLE_TIME" default:"15m" desc:"Maximum connection lifetime (despite the name; see Config)"`
+	DBConnectTimeout  time.Duration `envconfig:"DB_CONN_TIMEOUT" default:"15m" desc:"Connect timeout"`
+	HealthCheckPeriod time.Duration `envconfig:"DB_HEALTHCHECK_PERIOD" default:"1m" desc:"Pool health check interval"`
 }
 
 // NewPostgres initializes a *pgxpool.Pool.
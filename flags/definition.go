@@ -0,0 +1,156 @@
+package flags
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Definitions is the flag document, loaded from YAML through config.Loader:
+//
+//	flags:
+//	  new-checkout:
+//	    enabled: true              # kill switch: false turns the flag off for everyone
+//	    rollout: 25                # percent of principals, stable across requests
+//	    allow:
+//	      principals: [user-42]    # always on (still subject to deny and the kill switch)
+//	      services: [billing-api]
+//	    deny:
+//	      principals: [user-13]    # always off
+//	    entry_points: [http]       # only on for these entry points
+//	  search-ranker:
+//	    enabled: true
+//	    default: control           # served when the flag is off
+//	    variants:
+//	      control: 50
+//	      vector: 50
+type Definitions struct {
+	// Flags are YAML-only; there is no sensible env var form for them.
+	Flags map[string]Definition `yaml:"flags" ignored:"true" validate:"dive"`
+}
+
+// Definition configures a single flag. A flag without variants is boolean.
+type Definition struct {
+	Description string         `yaml:"description"`
+	Enabled     bool           `yaml:"enabled"`
+	Rollout     *float64       `yaml:"rollout" validate:"omitempty,min=0,max=100"` // nil means 100
+	Allow       Match          `yaml:"allow"`
+	Deny        Match          `yaml:"deny"`
+	EntryPoints []string       `yaml:"entry_points"`
+	Variants    map[string]int `yaml:"variants" validate:"dive,min=0"` // name -> relative weight
+	Default     string         `yaml:"default"`
+}
+
+// Match lists principals and calling services, as found in contextx.
+type Match struct {
+	Principals []string `yaml:"principals"`
+	Services   []string `yaml:"services"`
+}
+
+const (
+	VariantOn  = "on"
+	VariantOff = "off"
+
+	// Rollout resolution: 0.01%.
+	buckets = 10000
+)
+
+// flag is a compiled Definition, shaped for cheap evaluation.
+type flag struct {
+	key         string
+	enabled     bool
+	rollout     uint32 // in buckets
+	allow       matcher
+	deny        matcher
+	entryPoints map[string]bool
+	variants    []weighted // sorted by name so hashing is deterministic
+	total       uint32
+	off         string
+}
+
+type weighted struct {
+	name   string
+	weight uint32
+}
+
+type matcher struct {
+	principals map[string]bool
+	services   map[string]bool
+}
+
+func (m matcher) match(principal, service string) bool {
+	return (principal != "" && m.principals[principal]) || m.services[service]
+}
+
+func compile(defs *Definitions) (map[string]*flag, error) {
+	compiled := make(map[string]*flag, len(defs.Flags))
+	for key, d := range defs.Flags {
+		f, err := compileFlag(key, d)
+		if err != nil {
+			return nil, fmt.Errorf("flags: %s: %w", key, err)
+		}
+		compiled[key] = f
+	}
+	return compiled, nil
+}
+
+func compileFlag(key string, d Definition) (*flag, error) {
+	f := &flag{
+		key:         key,
+		enabled:     d.Enabled,
+		rollout:     buckets,
+		allow:       newMatcher(d.Allow),
+		deny:        newMatcher(d.Deny),
+		entryPoints: toSet(d.EntryPoints),
+		off:         VariantOff,
+	}
+
+	if d.Rollout != nil {
+		if *d.Rollout < 0 || *d.Rollout > 100 {
+			return nil, fmt.Errorf("rollout %v out of range 0-100", *d.Rollout)
+		}
+		f.rollout = uint32(*d.Rollout * buckets / 100)
+	}
+
+	if len(d.Variants) == 0 {
+		if d.Default != "" {
+			return nil, fmt.Errorf("default is only valid for variant flags")
+		}
+		return f, nil
+	}
+
+	for name, w := range d.Variants {
+		if w < 0 {
+			return nil, fmt.Errorf("variant %q has negative weight", name)
+		}
+		f.variants = append(f.variants, weighted{name: name, weight: uint32(w)})
+		f.total += uint32(w)
+	}
+	if f.total == 0 {
+		return nil, fmt.Errorf("variant weights sum to zero")
+	}
+	sort.Slice(f.variants, func(i, j int) bool { return f.variants[i].name < f.variants[j].name })
+
+	if d.Default == "" {
+		return nil, fmt.Errorf("variant flags need a default")
+	}
+	if _, ok := d.Variants[d.Default]; !ok {
+		return nil, fmt.Errorf("default %q is not a variant", d.Default)
+	}
+	f.off = d.Default
+	return f, nil
+}
+
+func newMatcher(m Match) matcher {
+	return matcher{principals: toSet(m.Principals), services: toSet(m.Services)}
+}
+
+func toSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
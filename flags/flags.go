@@ -0,0 +1,205 @@
+// Package flags evaluates feature flags per request from contextx values.
+package flags
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/godamri/helix-fnd/config"
+	"github.com/godamri/helix-fnd/pkg/contextx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Reasons explain an evaluation result.
+const (
+	ReasonUnknown    = "unknown"     // no such flag; treated as off
+	ReasonKilled     = "killed"      // enabled: false
+	ReasonDenied     = "denied"      // principal or service on the deny list
+	ReasonEntryPoint = "entry_point" // entry point not listed
+	ReasonAllowed    = "allowed"     // principal or service on the allow list
+	ReasonRollout    = "rollout"     // inside the rollout percentage
+	ReasonExcluded   = "excluded"    // outside the rollout percentage
+)
+
+// Evaluation is the result of evaluating one flag for one request.
+type Evaluation struct {
+	Key     string
+	Enabled bool
+	Variant string // "on"/"off" for boolean flags
+	Reason  string
+}
+
+// Client evaluates flags. The compiled set is swapped atomically on update,
+// so evaluation never takes a lock.
+type Client struct {
+	flags  atomic.Pointer[map[string]*flag]
+	record bool
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSpanAttributes records every evaluation on the active span as the
+// attributes feature_flag.<key>.variant and feature_flag.<key>.reason.
+func WithSpanAttributes() Option {
+	return func(c *Client) { c.record = true }
+}
+
+// New compiles defs. It fails on invalid definitions.
+func New(defs *Definitions, opts ...Option) (*Client, error) {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.Update(defs); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewFromReloader builds a Client that follows reloader. It also registers a
+// validator, so definitions that fail to compile are rejected by the reloader
+// itself and reloader.Current() keeps matching the rules the client uses.
+//
+//	loader := config.NewLoader[flags.Definitions]("FLAGS", "flags.yaml")
+//	reloader, err := config.NewReloader(loader, 0, logger)
+//	flagClient, err := flags.NewFromReloader(reloader, logger, flags.WithSpanAttributes())
+//	runner.Register("flags", reloader)
+func NewFromReloader(r *config.Reloader[Definitions], logger *slog.Logger, opts ...Option) (*Client, error) {
+	c, err := New(r.Current(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With("component", "flags")
+
+	r.AddValidator(func(next *Definitions) error {
+		_, err := compile(next)
+		return err
+	})
+
+	r.Subscribe(func(_, next *Definitions) {
+		if err := c.Update(next); err != nil {
+			logger.Error("Flag update rejected, keeping current flags", "error", err)
+			return
+		}
+		logger.Info("Flags updated", "count", len(next.Flags))
+	})
+	return c, nil
+}
+
+// Update replaces the flag set.
+func (c *Client) Update(defs *Definitions) error {
+	compiled, err := compile(defs)
+	if err != nil {
+		return err
+	}
+	c.flags.Store(&compiled)
+	return nil
+}
+
+// Enabled reports whether a flag is on for the request in ctx.
+func (c *Client) Enabled(ctx context.Context, key string) bool {
+	return c.Evaluate(ctx, key).Enabled
+}
+
+// Variant returns the variant served for the request in ctx.
+func (c *Client) Variant(ctx context.Context, key string) string {
+	return c.Evaluate(ctx, key).Variant
+}
+
+// Evaluate runs the rules in order: kill switch, deny list, entry point,
+// allow list, then percentage rollout.
+//
+// Rollout and variant assignment hash the flag key with the principal ID, so a
+// principal keeps its bucket across requests and replicas, and raising the
+// percentage only adds principals. Requests without a principal are only
+// included at 100%.
+func (c *Client) Evaluate(ctx context.Context, key string) Evaluation {
+	var ev Evaluation
+	if set := c.flags.Load(); set != nil {
+		if f, ok := (*set)[key]; ok {
+			ev = f.evaluate(ctx)
+		}
+	}
+	if ev.Key == "" {
+		ev = Evaluation{Key: key, Variant: VariantOff, Reason: ReasonUnknown}
+	}
+
+	if c.record {
+		record(ctx, ev)
+	}
+	return ev
+}
+
+func (f *flag) evaluate(ctx context.Context) Evaluation {
+	principal := contextx.GetAuthPrincipalID(ctx)
+	service := contextx.GetSourceService(ctx)
+
+	switch {
+	case !f.enabled:
+		return f.result(false, "", ReasonKilled)
+	case f.deny.match(principal, service):
+		return f.result(false, "", ReasonDenied)
+	case f.entryPoints != nil && !f.entryPoints[contextx.GetEntryPoint(ctx)]:
+		return f.result(false, "", ReasonEntryPoint)
+	case f.allow.match(principal, service):
+		return f.result(true, principal, ReasonAllowed)
+	case f.rollout >= buckets:
+		return f.result(true, principal, ReasonRollout)
+	case principal == "" || bucket(f.key, principal) >= f.rollout:
+		return f.result(false, "", ReasonExcluded)
+	default:
+		return f.result(true, principal, ReasonRollout)
+	}
+}
+
+func (f *flag) result(on bool, principal, reason string) Evaluation {
+	ev := Evaluation{Key: f.key, Enabled: on, Variant: f.off, Reason: reason}
+	if !on {
+		return ev
+	}
+	if f.variants == nil {
+		ev.Variant = VariantOn
+		return ev
+	}
+
+	// Salted differently from the rollout hash so variant split is independent of inclusion.
+	n := hash(f.key+"/variant", principal) % f.total
+	for _, v := range f.variants {
+		if n < v.weight {
+			ev.Variant = v.name
+			break
+		}
+		n -= v.weight
+	}
+	return ev
+}
+
+func bucket(key, principal string) uint32 {
+	return hash(key, principal) % buckets
+}
+
+func hash(key, principal string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(principal))
+	return h.Sum32()
+}
+
+func record(ctx context.Context, ev Evaluation) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		attribute.String("feature_flag."+ev.Key+".variant", ev.Variant),
+		attribute.String("feature_flag."+ev.Key+".reason", ev.Reason),
+	)
+}
@@ -0,0 +1,139 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/godamri/helix-fnd/config"
+	"github.com/godamri/helix-fnd/pkg/contextx"
+)
+
+func rollout(p float64) *float64 { return &p }
+
+func principal(id string) context.Context {
+	return contextx.WithAuthPrincipalID(context.Background(), id)
+}
+
+func TestRolloutBucketIsStable(t *testing.T) {
+	// Pinned: a different hash would move principals between buckets on deploy.
+	if got := bucket("new-checkout", "user-1"); got != 5649 {
+		t.Errorf("bucket = %d, want 5649", got)
+	}
+
+	at := func(p float64) *Client {
+		c, err := New(&Definitions{Flags: map[string]Definition{
+			"new-checkout": {Enabled: true, Rollout: rollout(p)},
+		}})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return c
+	}
+	c25, c25again, c50 := at(25), at(25), at(50)
+
+	on := 0
+	for i := 0; i < 10000; i++ {
+		ctx := principal(fmt.Sprintf("user-%d", i))
+		got := c25.Enabled(ctx, "new-checkout")
+		if got != c25again.Enabled(ctx, "new-checkout") {
+			t.Fatal("same principal evaluated differently by two clients")
+		}
+		if got && !c50.Enabled(ctx, "new-checkout") {
+			t.Fatal("raising the rollout dropped a principal")
+		}
+		if got {
+			on++
+		}
+	}
+	if on < 2200 || on > 2800 {
+		t.Errorf("%d of 10000 principals on at 25%%", on)
+	}
+
+	if c25.Enabled(context.Background(), "new-checkout") {
+		t.Error("request without a principal was included below 100%")
+	}
+}
+
+func TestTargetingRules(t *testing.T) {
+	c, err := New(&Definitions{Flags: map[string]Definition{
+		"f": {
+			Enabled:     true,
+			Rollout:     rollout(0),
+			Allow:       Match{Principals: []string{"vip", "blocked"}, Services: []string{"billing"}},
+			Deny:        Match{Principals: []string{"blocked"}},
+			EntryPoints: []string{"http"},
+		},
+		"killed": {Enabled: false, Allow: Match{Principals: []string{"vip"}}},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	http := func(ctx context.Context) context.Context { return contextx.WithEntryPoint(ctx, "http") }
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		key    string
+		on     bool
+		reason string
+	}{
+		{"allowed principal", http(principal("vip")), "f", true, ReasonAllowed},
+		{"allowed service", http(contextx.WithSourceService(context.Background(), "billing")), "f", true, ReasonAllowed},
+		{"deny beats allow", http(principal("blocked")), "f", false, ReasonDenied},
+		{"entry point not listed", contextx.WithEntryPoint(principal("vip"), "cron"), "f", false, ReasonEntryPoint},
+		{"outside rollout", http(principal("someone")), "f", false, ReasonExcluded},
+		{"kill switch beats allow", principal("vip"), "killed", false, ReasonKilled},
+		{"unknown flag", principal("vip"), "missing", false, ReasonUnknown},
+	}
+	for _, tt := range tests {
+		ev := c.Evaluate(tt.ctx, tt.key)
+		if ev.Enabled != tt.on || ev.Reason != tt.reason {
+			t.Errorf("%s: enabled=%v reason=%s, want %v %s", tt.name, ev.Enabled, ev.Reason, tt.on, tt.reason)
+		}
+	}
+}
+
+func TestReloadRejectsFlagsThatFailToCompile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("flags:\n  f:\n    enabled: true\n")
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reloader, err := config.NewReloader(config.NewLoader[Definitions]("HLXTEST_FLAGS", path), -1, logger)
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+	c, err := NewFromReloader(reloader, logger)
+	if err != nil {
+		t.Fatalf("NewFromReloader: %v", err)
+	}
+
+	// Passes struct validation, but a variant flag without a default does not compile.
+	write("flags:\n  f:\n    enabled: true\n    variants:\n      a: 1\n")
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("Reload accepted flags that do not compile")
+	}
+	if d := reloader.Current().Flags["f"]; len(d.Variants) != 0 {
+		t.Errorf("Current() = %+v, want the previous definitions", d)
+	}
+	if ev := c.Evaluate(context.Background(), "f"); !ev.Enabled || ev.Variant != VariantOn {
+		t.Errorf("Evaluate = %+v, want the previous boolean flag", ev)
+	}
+
+	write("flags:\n  f:\n    enabled: false\n")
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if c.Enabled(context.Background(), "f") {
+		t.Error("client did not follow a valid reload")
+	}
+}
@@ -11,8 +11,8 @@ import (
 )
 
 type Config struct {
-	Level  string `envconfig:"LOG_LEVEL" default:"info"`
-	Format string `envconfig:"LOG_FORMAT" default:"json"`
+	Level  string `envconfig:"LOG_LEVEL" default:"info" desc:"Logging level: debug, info, warn, error"`
+	Format string `envconfig:"LOG_FORMAT" default:"json" desc:"Output format: json or console"`
 }
 
 // sensitiveKeys defines fields that must be redacted.
@@ -92,6 +92,10 @@ func (c *Consumer) Start(ctx context.Context) error {
 	for {
 		// POLL
 		fetches := c.client.PollFetches(ctx)
+		if fetches.IsClientClosed() {
+			// Close() was called (e.g. by ConsumerManager during shutdown)
+			return nil
+		}
 		if err := fetches.Err(); err != nil {
 			if errors.Is(err, context.Canceled) {
 				return nil
@@ -10,6 +10,7 @@ const (
 	AuthPrincipalIDKey contextKey = "helix.auth_principal_id" // sub (siapa)
 	AuthSessionIDKey   contextKey = "helix.auth_session_id"   // jti / sid (tiket sesi mana)
 	AuthDecisionIDKey  contextKey = "helix.auth_decision_id"  // reference ke keputusan AuthZ (audit trail)
+	AuthScopesKey      contextKey = "helix.auth_scopes"       // OAuth2 scope claim, split on spaces
 
 	TraceIDKey       contextKey = "helix.trace_id"
 	ParentTraceIDKey contextKey = "helix.parent_trace_id"
@@ -69,6 +70,21 @@ func WithAuthDecisionID(ctx context.Context, v string) context.Context {
 	return context.WithValue(ctx, AuthDecisionIDKey, v)
 }
 
+func GetAuthScopes(ctx context.Context) []string { return getStringSlice(ctx, AuthScopesKey) }
+func WithAuthScopes(ctx context.Context, v []string) context.Context {
+	return context.WithValue(ctx, AuthScopesKey, v)
+}
+
+// HasAuthScope reports whether the authenticated caller was granted scope.
+func HasAuthScope(ctx context.Context, scope string) bool {
+	for _, s := range GetAuthScopes(ctx) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 func GetIdempotencyKey(ctx context.Context) string { return getString(ctx, IdempotencyKey, "") }
 func WithIdempotencyKey(ctx context.Context, v string) context.Context {
 	return context.WithValue(ctx, IdempotencyKey, v)
@@ -3,6 +3,7 @@ package health
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -15,6 +16,12 @@ import (
 type Checker struct {
 	db     *pgxpool.Pool
 	logger *slog.Logger
+	checks []namedCheck
+}
+
+type namedCheck struct {
+	name  string
+	check func(ctx context.Context) error
 }
 
 func NewChecker(db *pgxpool.Pool, logger *slog.Logger) *Checker {
@@ -24,6 +31,22 @@ func NewChecker(db *pgxpool.Pool, logger *slog.Logger) *Checker {
 	}
 }
 
+// AddCheck adds a readiness dependency next to the database, e.g. an audit fanout.
+// It must be called before the routes serve traffic. Its result is reported
+// under name, so name must be unique and not one of the built-in keys ("db",
+// "status"); AddCheck panics otherwise, as that is a wiring mistake.
+func (c *Checker) AddCheck(name string, check func(ctx context.Context) error) {
+	if name == "" || name == "db" || name == "status" {
+		panic(fmt.Sprintf("health: check name %q is reserved", name))
+	}
+	for _, nc := range c.checks {
+		if nc.name == name {
+			panic(fmt.Sprintf("health: check %q added twice", name))
+		}
+	}
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
 func (c *Checker) RegisterRoutes(r chi.Router) {
 	r.Get("/health", c.HandleHealth)   // Liveness
 	r.Get("/ready", c.HandleReadiness) // Readiness
@@ -40,17 +63,25 @@ func (c *Checker) HandleReadiness(w http.ResponseWriter, r *http.Request) {
 
 	status := "UP"
 	statusCode := http.StatusOK
+	response := map[string]string{"db": "UP"}
 
 	if err := c.db.Ping(ctx); err != nil {
 		c.logger.Error("readiness check failed: database unreachable or slow", "error", err)
 		status = "DOWN"
 		statusCode = http.StatusServiceUnavailable
+		response["db"] = "DOWN"
 	}
 
-	response := map[string]string{
-		"status": status,
-		"db":     status,
+	for _, nc := range c.checks {
+		response[nc.name] = "UP"
+		if err := nc.check(ctx); err != nil {
+			c.logger.Error("readiness check failed", "check", nc.name, "error", err)
+			status = "DOWN"
+			statusCode = http.StatusServiceUnavailable
+			response[nc.name] = "DOWN"
+		}
 	}
+	response["status"] = status
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/godamri/helix-fnd/audit"
+	"github.com/godamri/helix-fnd/pkg/contextx"
+)
+
+type AuditConfig struct {
+	Logger audit.Logger
+
+	// Masker masks the captured request body. If nil, the body is not captured:
+	// we never write a raw body just because masking was not wired up.
+	Masker *audit.Masker
+
+	// ExcludePaths are skipped, including everything below them ("/health" covers "/health/db").
+	ExcludePaths []string
+	// MaxBodySize caps the captured body. Larger bodies are recorded as truncated, without content.
+	MaxBodySize int64
+
+	ErrorLogger *slog.Logger
+}
+
+// NewAuditConfig builds an AuditConfig from audit.Config.
+func NewAuditConfig(logger audit.Logger, cfg audit.Config) (AuditConfig, error) {
+	masker, err := cfg.NewMasker()
+	if err != nil {
+		return AuditConfig{}, err
+	}
+	return AuditConfig{
+		Logger:       logger,
+		Masker:       masker,
+		ExcludePaths: cfg.ExcludePaths,
+		MaxBodySize:  cfg.MaxBodySize,
+	}, nil
+}
+
+// AuditMiddleware emits an audit.Event for every mutating request (POST, PUT, PATCH, DELETE).
+//
+// Mount it after the auth middleware so the principal is in the context.
+// The event is logged after the handler returns, so it carries the final status
+// and the chi route pattern ("POST /orders/{id}") rather than the raw path.
+func AuditMiddleware(cfg AuditConfig) func(http.Handler) http.Handler {
+	if cfg.ErrorLogger == nil {
+		cfg.ErrorLogger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isMutating(r.Method) || isExcluded(r.URL.Path, cfg.ExcludePaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			body, truncated := captureBody(r, cfg.MaxBodySize)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // handler wrote nothing
+			}
+
+			route := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			ctx := r.Context()
+			actor := contextx.GetAuthPrincipalID(ctx)
+			if actor == "" {
+				actor = "anonymous"
+			}
+
+			event := audit.Event{
+				ActorID:   actor,
+				Action:    r.Method + " " + route,
+				Resource:  r.URL.Path,
+				Timestamp: start,
+				TraceID:   contextx.GetTraceID(ctx),
+				Metadata: map[string]string{
+					"method":      r.Method,
+					"route":       route,
+					"status":      strconv.Itoa(status),
+					"request_id":  contextx.GetRequestID(ctx),
+					"remote_ip":   r.RemoteAddr,
+					"duration_ms": strconv.FormatInt(time.Since(start).Milliseconds(), 10),
+				},
+			}
+			if truncated {
+				event.Metadata["body_truncated"] = "true"
+			} else if len(body) > 0 && cfg.Masker != nil {
+				event.NewValue = maskBody(cfg.Masker, body, r.Header.Get("Content-Type"))
+			}
+
+			// The request is done; don't let client disconnects cancel a blocking audit write.
+			if err := cfg.Logger.Log(context.WithoutCancel(ctx), event); err != nil {
+				cfg.ErrorLogger.ErrorContext(ctx, "audit: failed to log request", "error", err, "action", event.Action)
+			}
+		})
+	}
+}
+
+// captureBody reads up to limit bytes and puts them back in front of the rest of the body,
+// so the handler still sees the full request.
+func captureBody(r *http.Request, limit int64) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody || limit <= 0 {
+		return nil, false
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if err != nil {
+		return nil, false
+	}
+
+	if int64(len(buf)) > limit {
+		return nil, true
+	}
+	return buf, false
+}
+
+// maskBody masks JSON bodies field by field. Anything else is not structured
+// enough to mask safely, so only its size is kept.
+func maskBody(m *audit.Masker, body []byte, contentType string) interface{} {
+	if strings.Contains(contentType, "json") {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber() // keep IDs and amounts exact
+		var v interface{}
+		if err := dec.Decode(&v); err == nil {
+			if masked, err := m.Mask(v); err == nil {
+				return masked
+			}
+		}
+	}
+	return map[string]string{"body": audit.Masked, "size": strconv.Itoa(len(body))}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isExcluded(path string, excluded []string) bool {
+	for _, p := range excluded {
+		if path == p || strings.HasPrefix(path, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
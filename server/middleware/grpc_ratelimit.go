@@ -18,7 +18,7 @@ import (
 // GRPCRateLimitInterceptor applies GCRA rate limiting to gRPC unary calls.
 // It prioritizes AuthPrincipalIDKey if present (authenticated service/user),
 // otherwise falls back to the peer's remote IP address.
-func GRPCRateLimitInterceptor(rdb *redis.Client, rate int, burst int, period time.Duration) grpc.UnaryServerInterceptor {
+func GRPCRateLimitInterceptor(rdb redis.UniversalClient, rate int, burst int, period time.Duration) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		// Rule: Boring beats clever. If rate is 0 or negative, skip the check.
 		if rate <= 0 {
@@ -12,7 +12,7 @@ import (
 type IdempotencyConfig struct {
 	HeaderKey   string
 	Expiry      time.Duration
-	RedisClient *redis.Client
+	RedisClient redis.UniversalClient
 	Logger      *slog.Logger
 
 	// True  = Availability First
@@ -60,7 +60,7 @@ var (
 // RateLimitMiddleware applies a static rate limit using Redis GCRA.
 // Added Circuit Breaker pattern. If Redis fails, fall back to in-memory rate limiting.
 // This prevents "Fail Open" from becoming "Database DDoS".
-func RateLimitMiddleware(rdb *redis.Client, rps int, burst int, period time.Duration) func(http.Handler) http.Handler {
+func RateLimitMiddleware(rdb redis.UniversalClient, rps int, burst int, period time.Duration) func(http.Handler) http.Handler {
 	// Initialize emergency limiter (Allow 2x normal traffic globally as fallback)
 	limiterOnce.Do(func() {
 		// Calculate global fallback rate (rough estimation)
@@ -52,5 +52,9 @@ func (s *JWTStrategy) Authenticate(ctx context.Context, payload AuthPayload) (co
 		ctx = contextx.WithAuthSessionID(ctx, claims.Sid)
 	}
 
+	if claims.Scope != "" {
+		ctx = contextx.WithAuthScopes(ctx, strings.Fields(claims.Scope))
+	}
+
 	return ctx, nil
 }